@@ -0,0 +1,149 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"io/ioutil"
+	"math/big"
+	"strings"
+)
+
+// Parameter is a named, typed function parameter.
+type Parameter struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Function is a contract function or event.
+type Function struct {
+	Name       string      `json:"name"`
+	Parameters []Parameter `json:"parameters"`
+	ReturnType string      `json:"returntype"`
+}
+
+// ABI is the contents of a .abi.json file written by the NEO compiler.
+type ABI struct {
+	Hash       string     `json:"hash"`
+	EntryPoint string     `json:"entrypoint"`
+	Functions  []Function `json:"functions"`
+	Events     []Function `json:"events"`
+}
+
+// Load reads and parses an .abi.json file.
+func Load(path string) (*ABI, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses the contents of an .abi.json file and checks its types.
+func Parse(data []byte) (*ABI, error) {
+	a := &ABI{}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, err
+	}
+	for _, fns := range [][]Function{a.Functions, a.Events} {
+		for _, fn := range fns {
+			if _, err := fn.Types(); err != nil {
+				return nil, fmt.Errorf("function %s: %v", fn.Name, err)
+			}
+			if _, err := neo.ParseContractParameterType(fn.ReturnType); err != nil {
+				return nil, fmt.Errorf("function %s: %v", fn.Name, err)
+			}
+		}
+	}
+	return a, nil
+}
+
+// ScriptHash returns the contract script hash.
+func (a *ABI) ScriptHash() (utils.Uint160, error) {
+	return utils.Uint160DecodeString(strings.TrimPrefix(a.Hash, "0x"))
+}
+
+// Function returns the function called name.
+func (a *ABI) Function(name string) (*Function, bool) {
+	for i := range a.Functions {
+		if a.Functions[i].Name == name {
+			return &a.Functions[i], true
+		}
+	}
+	return nil, false
+}
+
+// Types returns the parameter types of fn.
+func (fn *Function) Types() ([]neo.ContractParameterType, error) {
+	types := make([]neo.ContractParameterType, len(fn.Parameters))
+	for i, p := range fn.Parameters {
+		t, err := neo.ParseContractParameterType(p.Type)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = t
+	}
+	return types, nil
+}
+
+// Return returns the return type of fn.
+func (fn *Function) Return() neo.ContractParameterType {
+	t, _ := neo.ParseContractParameterType(fn.ReturnType)
+	return t
+}
+
+// EmitArgs validates args against the parameters of fn and pushes them in
+// calling order, the first argument ending on top of the stack.
+func (fn *Function) EmitArgs(sb *neo.ScriptBuilder, args ...interface{}) error {
+	types, err := fn.Types()
+	if err != nil {
+		return err
+	}
+	if len(args) != len(types) {
+		return fmt.Errorf("%s takes %d arguments, got %d", fn.Name, len(types), len(args))
+	}
+	for i := len(args) - 1; i >= 0; i-- {
+		if err := EmitParameter(sb, types[i], args[i]); err != nil {
+			return fmt.Errorf("%s argument %s: %v", fn.Name, fn.Parameters[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// InvocationScript returns the script calling function with args. The entry
+// point takes its arguments directly; other functions are called through the
+// entry point with their name and their arguments packed in an array.
+func (a *ABI) InvocationScript(function string, args ...interface{}) ([]byte, error) {
+	fn, ok := a.Function(function)
+	if !ok {
+		return nil, fmt.Errorf("function %s not found", function)
+	}
+	hash, err := a.ScriptHash()
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &neo.ScriptBuilder{}
+	if err := fn.EmitArgs(sb, args...); err != nil {
+		return nil, err
+	}
+	if fn.Name != a.EntryPoint {
+		sb.EmitPushNumber(*big.NewInt(int64(len(args))))
+		sb.Emit(opcode.PACK, nil)
+		sb.EmitPushString(fn.Name)
+	}
+	sb.EmitAppCall(hash.BytesReverse(), false)
+	return sb.ToBytes(), nil
+}
+
+// DecodeResult decodes the value function returned, according to its declared return type.
+func (a *ABI) DecodeResult(function string, item vm.StackItem) (interface{}, error) {
+	fn, ok := a.Function(function)
+	if !ok {
+		return nil, fmt.Errorf("function %s not found", function)
+	}
+	return DecodeItem(fn.Return(), item)
+}
@@ -0,0 +1,119 @@
+package abi
+
+import (
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+	"testing"
+)
+
+const testABI = `{
+	"hash": "0x5b7074e873973a6ed3708862f219a6fbf4d1c411",
+	"entrypoint": "Main",
+	"functions": [
+		{"name": "Main", "parameters": [{"name": "operation", "type": "String"}, {"name": "args", "type": "Array"}], "returntype": "ByteArray"},
+		{"name": "add", "parameters": [{"name": "a", "type": "Integer"}, {"name": "b", "type": "Integer"}], "returntype": "Integer"},
+		{"name": "balanceOf", "parameters": [{"name": "account", "type": "Hash160"}], "returntype": "Integer"}
+	],
+	"events": [
+		{"name": "transfer", "parameters": [{"name": "from", "type": "ByteArray"}, {"name": "to", "type": "ByteArray"}, {"name": "amount", "type": "Integer"}], "returntype": "Void"}
+	]
+}`
+
+func TestInvocationScript(t *testing.T) {
+	a, err := Parse([]byte(testABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Main(operation, args): drop the operation and add args[0] and args[1].
+	contracts := vm.MemoryContracts{}
+	hash := contracts.Add(&vm.Contract{Script: []byte{
+		opcode.DROP, opcode.DUP, opcode.PUSH0, opcode.PICKITEM,
+		opcode.SWAP, opcode.PUSH1, opcode.PICKITEM, opcode.ADD, opcode.RET,
+	}})
+	a.Hash = "0x" + hash.String()
+
+	script, err := a.InvocationScript("add", 3, big.NewInt(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := vm.NewEngine(vm.Application, nil)
+	e.Contracts = contracts
+	result := e.Run(script)
+	if result.State != vm.HALT {
+		t.Fatalf("invocation %s: %v", result.State, result.Err)
+	}
+	v, err := a.DecodeResult("add", result.Stack[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.(*big.Int).Int64() != 7 {
+		t.Fatalf("add returned %v", v)
+	}
+
+	if _, err := a.InvocationScript("add", 3); err == nil {
+		t.Fatal("expected argument count error")
+	}
+	if _, err := a.InvocationScript("add", 3, "4"); err == nil {
+		t.Fatal("expected argument type error")
+	}
+	if _, err := a.InvocationScript("balanceOf", "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.InvocationScript("balanceOf", "not an address"); err == nil {
+		t.Fatal("expected address error")
+	}
+	if _, err := a.InvocationScript("missing"); err == nil {
+		t.Fatal("expected unknown function error")
+	}
+}
+
+func TestDecodeItem(t *testing.T) {
+	hash, _ := utils.Uint160DecodeString("5b7074e873973a6ed3708862f219a6fbf4d1c411")
+	v, err := DecodeItem(neo.Hash160Type, vm.NewByteArray(hash.BytesReverse()))
+	if err != nil || !v.(utils.Uint160).Equals(hash) {
+		t.Fatalf("decode Hash160: %v %v", v, err)
+	}
+}
+
+func TestMapParameter(t *testing.T) {
+	a, err := Parse([]byte(`{
+		"hash": "0x5b7074e873973a6ed3708862f219a6fbf4d1c411",
+		"entrypoint": "Main",
+		"functions": [
+			{"name": "Main", "parameters": [{"name": "operation", "type": "String"}, {"name": "args", "type": "Array"}], "returntype": "ByteArray"},
+			{"name": "echo", "parameters": [{"name": "m", "type": "Map"}], "returntype": "Map"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Main(operation, args) returns args[0].
+	contracts := vm.MemoryContracts{}
+	hash := contracts.Add(&vm.Contract{Script: []byte{opcode.DROP, opcode.PUSH0, opcode.PICKITEM, opcode.RET}})
+	a.Hash = "0x" + hash.String()
+
+	script, err := a.InvocationScript("echo", map[string]interface{}{"a": 1, "b": []interface{}{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := vm.NewEngine(vm.Application, nil)
+	e.Contracts = contracts
+	result := e.Run(script)
+	if result.State != vm.HALT {
+		t.Fatalf("invocation %s: %v", result.State, result.Err)
+	}
+	v, err := a.DecodeResult("echo", result.Stack[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.(*vm.Map)
+	if item, ok := m.Get(vm.NewByteArray([]byte("a"))); !ok || item.GetBigInteger().Int64() != 1 || len(m.Keys) != 2 {
+		t.Fatalf("echo returned %s", vm.ItemString(m))
+	}
+	if _, err := DecodeItem(neo.MapType, vm.NewInteger(big.NewInt(1))); err == nil {
+		t.Fatal("expected error decoding an integer as a map")
+	}
+}
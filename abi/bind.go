@@ -0,0 +1,217 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// goTypes maps parameter types to the Go types of generated bindings.
+var goTypes = map[neo.ContractParameterType]string{
+	neo.SignatureType:        "[]byte",
+	neo.BooleanType:          "bool",
+	neo.IntegerType:          "*big.Int",
+	neo.Hash160Type:          "utils.Uint160",
+	neo.Hash256Type:          "utils.Uint256",
+	neo.ByteArrayType:        "[]byte",
+	neo.PublicKeyType:        "[]byte",
+	neo.StringType:           "string",
+	neo.ArrayType:            "[]interface{}",
+	neo.MapType:              "map[string]interface{}",
+	neo.InteropInterfaceType: "vm.StackItem",
+}
+
+type bindParam struct {
+	Name   string
+	GoType string
+}
+
+type bindFunction struct {
+	Name       string
+	GoName     string
+	Params     []bindParam
+	ReturnType string
+}
+
+type bindContract struct {
+	Package   string
+	Type      string
+	ABI       string
+	Functions []bindFunction
+	Imports   []string
+}
+
+// Bind generates the source of a Go package with typed bindings for the
+// contract described by a: one method per function building its invocation
+// script, and one Decode method per function converting its result.
+func Bind(a *ABI, pkg, typeName string) ([]byte, error) {
+	if _, err := a.ScriptHash(); err != nil {
+		return nil, fmt.Errorf("invalid contract hash %s: %v", a.Hash, err)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &bindContract{Package: pkg, Type: typeName, ABI: strconv.Quote(string(data))}
+	imports := map[string]bool{"github.com/hzxiao/neo-thinsdk-go/abi": true, "github.com/hzxiao/neo-thinsdk-go/utils": true}
+	need := func(goType string) {
+		switch {
+		case strings.Contains(goType, "big."):
+			imports["math/big"] = true
+		case strings.Contains(goType, "vm."):
+			imports["github.com/hzxiao/neo-thinsdk-go/vm"] = true
+		}
+	}
+	names := map[string]bool{"ScriptHash": true}
+	for _, fn := range a.Functions {
+		types, err := fn.Types()
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %v", fn.Name, err)
+		}
+		f := bindFunction{Name: fn.Name, GoName: exportedName(fn.Name)}
+		if names[f.GoName] || names["Decode"+f.GoName] {
+			return nil, fmt.Errorf("function %s: duplicate Go name %s", fn.Name, f.GoName)
+		}
+		names[f.GoName], names["Decode"+f.GoName] = true, true
+
+		for i, t := range types {
+			goType := goTypes[t]
+			if goType == "" || t == neo.InteropInterfaceType {
+				return nil, fmt.Errorf("function %s: %s can not be passed as a parameter", fn.Name, t)
+			}
+			need(goType)
+			f.Params = append(f.Params, bindParam{Name: paramName(fn.Parameters[i].Name, i), GoType: goType})
+		}
+		if rt := fn.Return(); rt != neo.VoidType {
+			f.ReturnType = goTypes[rt]
+			switch rt {
+			case neo.ArrayType:
+				f.ReturnType = "[]vm.StackItem"
+			case neo.MapType:
+				f.ReturnType = "*vm.Map"
+			}
+			need(f.ReturnType)
+			imports["github.com/hzxiao/neo-thinsdk-go/vm"] = true
+		}
+		c.Functions = append(c.Functions, f)
+	}
+	for path := range imports {
+		c.Imports = append(c.Imports, path)
+	}
+	sort.Strings(c.Imports)
+
+	var buf bytes.Buffer
+	if err := bindTemplate.Execute(&buf, c); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// exportedName turns a contract function name into an exported Go identifier.
+func exportedName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "F" + s
+	}
+	return s
+}
+
+// paramName turns a parameter name into an unexported Go identifier that
+// does not clash with keywords or the generated receiver.
+func paramName(name string, i int) string {
+	s := exportedName(name)
+	if name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	s = string(r)
+	if token.Lookup(s).IsKeyword() || s == "c" {
+		s += "Arg"
+	}
+	return s
+}
+
+var bindTemplate = template.Must(template.New("bind").Parse(`// Code generated by abigen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// {{.Type}}ABI is the ABI the {{.Type}} binding was generated from.
+const {{.Type}}ABI = {{.ABI}}
+
+// {{.Type}} builds invocation scripts and decodes results for the contract.
+type {{.Type}} struct {
+	abi *abi.ABI
+}
+
+// New{{.Type}} returns a binding to the contract at the hash in its ABI.
+func New{{.Type}}() (*{{.Type}}, error) {
+	a, err := abi.Parse([]byte({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{abi: a}, nil
+}
+
+// New{{.Type}}At returns a binding to the same contract deployed at hash.
+func New{{.Type}}At(hash utils.Uint160) (*{{.Type}}, error) {
+	c, err := New{{.Type}}()
+	if err != nil {
+		return nil, err
+	}
+	c.abi.Hash = "0x" + hash.String()
+	return c, nil
+}
+
+// ScriptHash returns the script hash of the contract.
+func (c *{{.Type}}) ScriptHash() utils.Uint160 {
+	hash, _ := c.abi.ScriptHash()
+	return hash
+}
+{{$type := .Type}}
+{{- range .Functions}}
+// {{.GoName}} returns the script invoking {{.Name}}.
+func (c *{{$type}}) {{.GoName}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}} {{$p.GoType}}{{end}}) ([]byte, error) {
+	return c.abi.InvocationScript({{printf "%q" .Name}}{{range .Params}}, {{.Name}}{{end}})
+}
+{{if .ReturnType}}
+// Decode{{.GoName}} decodes the value returned by {{.Name}}.
+func (c *{{$type}}) Decode{{.GoName}}(item vm.StackItem) ({{.ReturnType}}, error) {
+	v, err := c.abi.DecodeResult({{printf "%q" .Name}}, item)
+	if err != nil {
+		var zero {{.ReturnType}}
+		return zero, err
+	}
+	return v.({{.ReturnType}}), nil
+}
+{{end}}
+{{- end}}
+`))
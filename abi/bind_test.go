@@ -0,0 +1,64 @@
+package abi
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+// checkBinding parses and type-checks generated code against the packages
+// it imports.
+func checkBinding(t *testing.T, code []byte) *ast.File {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "token.go", code, 0)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, code)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("token", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("%v\n%s", err, code)
+	}
+	return f
+}
+
+func TestBind(t *testing.T) {
+	a, err := Parse([]byte(testABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Bind(a, "token", "Token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := checkBinding(t, code)
+
+	methods := map[string]*ast.FuncDecl{}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+			methods[fn.Name.Name] = fn
+		}
+	}
+	for _, name := range []string{"Main", "DecodeMain", "Add", "DecodeAdd", "BalanceOf", "DecodeBalanceOf", "ScriptHash"} {
+		if methods[name] == nil {
+			t.Fatalf("missing method %s\n%s", name, code)
+		}
+	}
+	if n := methods["Add"].Type.Params.NumFields(); n != 2 {
+		t.Fatalf("Add takes %d parameters", n)
+	}
+
+	// Backticks in names survive in the embedded ABI.
+	a.Functions = append(a.Functions, Function{Name: "say`hi", Parameters: []Parameter{{Name: "`", Type: "Map"}}, ReturnType: "Map"})
+	code, err = Bind(a, "token", "Token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBinding(t, code)
+
+	if exportedName("get_owner") != "GetOwner" || paramName("type", 0) != "typeArg" || paramName("", 1) != "arg1" {
+		t.Fatal("generated name error")
+	}
+}
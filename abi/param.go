@@ -0,0 +1,228 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+	"sort"
+)
+
+// EmitParameter pushes v as a parameter of type t.
+//
+// Hash160 takes a utils.Uint160, a NEO address or 20 little-endian bytes;
+// Hash256 a utils.Uint256 or 32 bytes; Integer any Go integer or *big.Int;
+// Array a []interface{} whose elements are pushed by their Go type; Map a
+// map[string]interface{}, whose values are pushed the same way.
+func EmitParameter(sb *neo.ScriptBuilder, t neo.ContractParameterType, v interface{}) error {
+	switch t {
+	case neo.BooleanType:
+		b, ok := v.(bool)
+		if !ok {
+			return typeError(t, v)
+		}
+		sb.EmitPushBool(b)
+	case neo.IntegerType:
+		n, ok := toBigInt(v)
+		if !ok {
+			return typeError(t, v)
+		}
+		sb.EmitPushNumber(*n)
+	case neo.Hash160Type:
+		var data []byte
+		switch h := v.(type) {
+		case utils.Uint160:
+			data = h.BytesReverse()
+		case string:
+			hash, ok := neo.GetPublicKeyHashFromAddress(h)
+			if !ok {
+				return fmt.Errorf("invalid address %s", h)
+			}
+			data = hash
+		case []byte:
+			data = h
+		default:
+			return typeError(t, v)
+		}
+		if len(data) != 20 {
+			return errors.New("Hash160 must be 20 bytes")
+		}
+		sb.EmitPushBytes(data)
+	case neo.Hash256Type:
+		var data []byte
+		switch h := v.(type) {
+		case utils.Uint256:
+			data = h.Bytes()
+		case []byte:
+			data = h
+		default:
+			return typeError(t, v)
+		}
+		if len(data) != 32 {
+			return errors.New("Hash256 must be 32 bytes")
+		}
+		sb.EmitPushBytes(data)
+	case neo.ByteArrayType, neo.SignatureType, neo.PublicKeyType:
+		data, ok := v.([]byte)
+		if !ok {
+			return typeError(t, v)
+		}
+		if t == neo.SignatureType && len(data) != 64 {
+			return errors.New("Signature must be 64 bytes")
+		}
+		if t == neo.PublicKeyType && len(data) != 33 {
+			return errors.New("PublicKey must be 33 bytes")
+		}
+		sb.EmitPushBytes(data)
+	case neo.StringType:
+		s, ok := v.(string)
+		if !ok {
+			return typeError(t, v)
+		}
+		sb.EmitPushString(s)
+	case neo.ArrayType:
+		items, ok := v.([]interface{})
+		if !ok {
+			return typeError(t, v)
+		}
+		for i := len(items) - 1; i >= 0; i-- {
+			if err := emitValue(sb, items[i]); err != nil {
+				return err
+			}
+		}
+		sb.EmitPushNumber(*big.NewInt(int64(len(items))))
+		sb.Emit(opcode.PACK, nil)
+	case neo.MapType:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return typeError(t, v)
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sb.Emit(opcode.NEWMAP, nil)
+		for _, key := range keys {
+			sb.Emit(opcode.DUP, nil)
+			sb.EmitPushString(key)
+			if err := emitValue(sb, m[key]); err != nil {
+				return err
+			}
+			sb.Emit(opcode.SETITEM, nil)
+		}
+	default:
+		return fmt.Errorf("%s can not be passed as a parameter", t)
+	}
+	return nil
+}
+
+// emitValue pushes an array element or map value according to its Go type.
+func emitValue(sb *neo.ScriptBuilder, v interface{}) error {
+	switch v.(type) {
+	case bool:
+		return EmitParameter(sb, neo.BooleanType, v)
+	case string:
+		return EmitParameter(sb, neo.StringType, v)
+	case []byte:
+		return EmitParameter(sb, neo.ByteArrayType, v)
+	case utils.Uint160:
+		return EmitParameter(sb, neo.Hash160Type, v)
+	case utils.Uint256:
+		return EmitParameter(sb, neo.Hash256Type, v)
+	case []interface{}:
+		return EmitParameter(sb, neo.ArrayType, v)
+	case map[string]interface{}:
+		return EmitParameter(sb, neo.MapType, v)
+	}
+	if _, ok := toBigInt(v); ok {
+		return EmitParameter(sb, neo.IntegerType, v)
+	}
+	return fmt.Errorf("unsupported value %T", v)
+}
+
+func toBigInt(v interface{}) (*big.Int, bool) {
+	switch n := v.(type) {
+	case *big.Int:
+		return n, n != nil
+	case big.Int:
+		return &n, true
+	case int:
+		return big.NewInt(int64(n)), true
+	case int8:
+		return big.NewInt(int64(n)), true
+	case int16:
+		return big.NewInt(int64(n)), true
+	case int32:
+		return big.NewInt(int64(n)), true
+	case int64:
+		return big.NewInt(n), true
+	case uint:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint8:
+		return big.NewInt(int64(n)), true
+	case uint16:
+		return big.NewInt(int64(n)), true
+	case uint32:
+		return big.NewInt(int64(n)), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	}
+	return nil, false
+}
+
+func typeError(t neo.ContractParameterType, v interface{}) error {
+	return fmt.Errorf("can not use %T as %s", v, t)
+}
+
+// DecodeItem converts a stack item returned by a contract into the Go value
+// matching t: bool, *big.Int, utils.Uint160, utils.Uint256, []byte, string,
+// []vm.StackItem for arrays, *vm.Map for maps, nil for Void and the item
+// itself for interop interfaces.
+func DecodeItem(t neo.ContractParameterType, item vm.StackItem) (result interface{}, err error) {
+	if t == neo.VoidType {
+		return nil, nil
+	}
+	if item == nil {
+		return nil, errors.New("missing return value")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("can not decode %s as %s", vm.ItemString(item), t)
+		}
+	}()
+
+	switch t {
+	case neo.BooleanType:
+		return item.GetBoolean(), nil
+	case neo.IntegerType:
+		return item.GetBigInteger(), nil
+	case neo.Hash160Type:
+		return utils.Uint160DecodeBytes(utils.BytesReverse(item.GetByteArray()))
+	case neo.Hash256Type:
+		return utils.Uint256DecodeBytes(utils.BytesReverse(item.GetByteArray()))
+	case neo.ByteArrayType, neo.SignatureType, neo.PublicKeyType:
+		return item.GetByteArray(), nil
+	case neo.StringType:
+		return string(item.GetByteArray()), nil
+	case neo.ArrayType:
+		switch v := item.(type) {
+		case *vm.Array:
+			return v.Items, nil
+		case *vm.Struct:
+			return v.Items, nil
+		}
+		return nil, fmt.Errorf("can not decode %s as %s", vm.ItemString(item), t)
+	case neo.MapType:
+		if m, ok := item.(*vm.Map); ok {
+			return m, nil
+		}
+		return nil, fmt.Errorf("can not decode %s as %s", vm.ItemString(item), t)
+	case neo.InteropInterfaceType:
+		return item, nil
+	}
+	return nil, fmt.Errorf("unknown return type %s", t)
+}
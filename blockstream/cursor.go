@@ -0,0 +1,79 @@
+package blockstream
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CursorStore persists the height a Stream resumes from.
+type CursorStore interface {
+	// LoadCursor returns the saved height; ok is false when none was saved.
+	LoadCursor() (height uint32, ok bool, err error)
+	SaveCursor(height uint32) error
+}
+
+// MemoryCursor is a CursorStore keeping the height in memory.
+type MemoryCursor struct {
+	mu     sync.Mutex
+	height uint32
+	saved  bool
+}
+
+// LoadCursor implements CursorStore.
+func (c *MemoryCursor) LoadCursor() (uint32, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.height, c.saved, nil
+}
+
+// SaveCursor implements CursorStore.
+func (c *MemoryCursor) SaveCursor(height uint32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.height, c.saved = height, true
+	return nil
+}
+
+// FileCursor is a CursorStore keeping the height as text in the file at Path.
+type FileCursor struct {
+	Path string
+}
+
+// LoadCursor implements CursorStore.
+func (c *FileCursor) LoadCursor() (uint32, bool, error) {
+	data, err := ioutil.ReadFile(c.Path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	height, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return 0, false, err
+	}
+	return uint32(height), true, nil
+}
+
+// SaveCursor implements CursorStore. The file is replaced atomically so a
+// crash never leaves a partial height behind.
+func (c *FileCursor) SaveCursor(height uint32) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(c.Path), filepath.Base(c.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(strconv.FormatUint(uint64(height), 10) + "\n"); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.Path)
+}
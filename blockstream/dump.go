@@ -0,0 +1,68 @@
+package blockstream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"io"
+)
+
+// DumpReader reads the blocks of a chain.acc dump, as exported by neo-cli.
+type DumpReader struct {
+	// Start is the index of the first block and Count the number of blocks.
+	Start uint32
+	Count uint32
+
+	r    io.Reader
+	read uint32
+}
+
+// NewDumpReader reads the header of the dump in r. Dumps named chain.N.acc
+// begin with the index of their first block, hasStart tells whether r is one;
+// chain.acc dumps start at the genesis block.
+func NewDumpReader(r io.Reader, hasStart bool) (*DumpReader, error) {
+	d := &DumpReader{r: r}
+	var err error
+	if hasStart {
+		if d.Start, err = d.readUint32(); err != nil {
+			return nil, err
+		}
+	}
+	if d.Count, err = d.readUint32(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *DumpReader) readUint32() (uint32, error) {
+	data := make([]byte, 4)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+// Next returns the next block of the dump, io.EOF after the last one.
+func (d *DumpReader) Next() (*neo.Block, error) {
+	if d.read == d.Count {
+		return nil, io.EOF
+	}
+	index := d.Start + d.read
+	size, err := d.readUint32()
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %v", index, err)
+	}
+	if size > 0x02000000 {
+		return nil, fmt.Errorf("block %d: size %d too large", index, size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, fmt.Errorf("block %d: %v", index, err)
+	}
+	block, err := neo.DecodeBlock(data)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %v", index, err)
+	}
+	d.read++
+	return block, nil
+}
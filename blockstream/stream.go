@@ -0,0 +1,236 @@
+// Package blockstream delivers the blocks of a chain in order, resuming
+// where it stopped.
+package blockstream
+
+import (
+	"context"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"sync"
+	"time"
+)
+
+// Source provides serialized blocks. *rpc.Client is a Source.
+type Source interface {
+	GetBlockCount(ctx context.Context) (uint32, error)
+	GetRawBlock(ctx context.Context, index uint32) ([]byte, error)
+}
+
+// Stream fetches blocks from a Source ahead of the consumer and delivers
+// them in order.
+//
+// The cursor saved after a block is handed over is the index of that block:
+// the unbuffered channel only hands over block n once the consumer is done
+// with block n-1, so a restarted stream delivers block n again. Consumers
+// must therefore tolerate seeing the last block twice.
+type Stream struct {
+	Source Source
+	// Cursor, when set, keeps the height to resume from.
+	Cursor CursorStore
+	// Start is the first height when Cursor holds none.
+	Start uint32
+	// Concurrency is the number of blocks fetched at the same time.
+	Concurrency int
+	// PollInterval is how often the block count is polled at the tip.
+	PollInterval time.Duration
+	// Retries is the number of times a failed call is retried, waiting
+	// RetryDelay, doubled on every attempt, in between.
+	Retries    int
+	RetryDelay time.Duration
+
+	mu  sync.Mutex
+	err error
+}
+
+// New returns a stream of the blocks of source with default settings.
+func New(source Source, cursor CursorStore) *Stream {
+	return &Stream{
+		Source:       source,
+		Cursor:       cursor,
+		Concurrency:  4,
+		PollInterval: 5 * time.Second,
+		Retries:      5,
+		RetryDelay:   time.Second,
+	}
+}
+
+type fetched struct {
+	block *neo.Block
+	err   error
+}
+
+// Blocks starts the stream and returns the channel delivering the blocks.
+// The channel is closed when ctx is done or the stream fails; Err then
+// tells why.
+func (s *Stream) Blocks(ctx context.Context) <-chan *neo.Block {
+	blocks := make(chan *neo.Block)
+	go func() {
+		defer close(blocks)
+		s.setErr(s.run(ctx, blocks))
+	}()
+	return blocks
+}
+
+// Err returns the error that ended the stream, nil while it runs.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *Stream) run(ctx context.Context, blocks chan<- *neo.Block) error {
+	next := s.Start
+	if s.Cursor != nil {
+		height, ok, err := s.Cursor.LoadCursor()
+		if err != nil {
+			return fmt.Errorf("load cursor: %v", err)
+		}
+		if ok {
+			next = height
+		}
+	}
+	concurrency := s.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	// pending holds the results of the blocks being fetched, in height order.
+	pending := make(chan chan fetched, concurrency-1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		s.dispatch(ctx, next, pending, &wg)
+	}()
+
+	for result := range pending {
+		var r fetched
+		select {
+		case r = <-result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return r.err
+		}
+		select {
+		case blocks <- r.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if s.Cursor != nil {
+			if err := s.Cursor.SaveCursor(r.block.Index); err != nil {
+				return fmt.Errorf("save cursor: %v", err)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// dispatch starts fetching every height from next as the chain reaches it,
+// queueing the results on pending until ctx is done.
+func (s *Stream) dispatch(ctx context.Context, next uint32, pending chan<- chan fetched, wg *sync.WaitGroup) {
+	var count uint32
+	for {
+		for next >= count {
+			var err error
+			count, err = s.blockCount(ctx)
+			if err != nil {
+				result := make(chan fetched, 1)
+				result <- fetched{err: err}
+				select {
+				case pending <- result:
+				case <-ctx.Done():
+				}
+				return
+			}
+			if next >= count && !sleep(ctx, s.PollInterval) {
+				return
+			}
+		}
+
+		result := make(chan fetched, 1)
+		select {
+		case pending <- result:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func(index uint32) {
+			defer wg.Done()
+			block, err := s.fetch(ctx, index)
+			result <- fetched{block: block, err: err}
+		}(next)
+		next++
+	}
+}
+
+func (s *Stream) blockCount(ctx context.Context) (uint32, error) {
+	var count uint32
+	err := s.retry(ctx, func() (err error) {
+		count, err = s.Source.GetBlockCount(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("getblockcount: %v", err)
+	}
+	return count, nil
+}
+
+func (s *Stream) fetch(ctx context.Context, index uint32) (*neo.Block, error) {
+	var block *neo.Block
+	err := s.retry(ctx, func() error {
+		raw, err := s.Source.GetRawBlock(ctx, index)
+		if err != nil {
+			return err
+		}
+		block, err = neo.DecodeBlock(raw)
+		if err == nil && block.Index != index {
+			err = fmt.Errorf("got block %d", block.Index)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %v", index, err)
+	}
+	return block, nil
+}
+
+// retry calls fn until it succeeds, the retries are exhausted or ctx is done.
+func (s *Stream) retry(ctx context.Context, fn func() error) error {
+	delay := s.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= s.Retries || ctx.Err() != nil {
+			return err
+		}
+		if !sleep(ctx, delay) {
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// sleep waits for d, reporting false when ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
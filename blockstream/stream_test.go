@@ -0,0 +1,139 @@
+package blockstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chain is a Source whose blocks take a random time to fetch and fail as
+// many times as failures says.
+type chain struct {
+	mu       sync.Mutex
+	count    uint32
+	failures map[uint32]int
+	active   int
+	peak     int
+}
+
+func (c *chain) GetBlockCount(ctx context.Context) (uint32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count, nil
+}
+
+func (c *chain) GetRawBlock(ctx context.Context, index uint32) ([]byte, error) {
+	c.mu.Lock()
+	c.active++
+	if c.active > c.peak {
+		c.peak = c.active
+	}
+	c.mu.Unlock()
+	time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	if index >= c.count {
+		return nil, errors.New("unknown block")
+	}
+	if c.failures[index] > 0 {
+		c.failures[index]--
+		return nil, errors.New("connection reset")
+	}
+	block := &neo.Block{Header: neo.Header{Index: index}}
+	buf := &bytes.Buffer{}
+	block.Serialize(buf)
+	return buf.Bytes(), nil
+}
+
+func (c *chain) grow(n uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count += n
+}
+
+func newStream(c *chain, cursor CursorStore) *Stream {
+	s := New(c, cursor)
+	s.Concurrency = 3
+	s.PollInterval = time.Millisecond
+	s.Retries = 2
+	s.RetryDelay = time.Millisecond
+	return s
+}
+
+func TestStream(t *testing.T) {
+	c := &chain{count: 10, failures: map[uint32]int{2: 2, 7: 1}}
+	cursor := &MemoryCursor{}
+	s := newStream(c, cursor)
+	s.Start = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	blocks := s.Blocks(ctx)
+	for i := uint32(1); i < 12; i++ {
+		if i == 10 {
+			c.grow(2)
+		}
+		block, ok := <-blocks
+		if !ok {
+			t.Fatalf("stream ended at %d: %v", i, s.Err())
+		}
+		if block.Index != i {
+			t.Fatalf("got block %d, expected %d", block.Index, i)
+		}
+	}
+	cancel()
+	for range blocks {
+	}
+	if s.Err() != context.Canceled {
+		t.Fatalf("stream error %v", s.Err())
+	}
+	if c.peak > 3 {
+		t.Fatalf("%d blocks fetched at the same time", c.peak)
+	}
+	if height, ok, _ := cursor.LoadCursor(); !ok || height != 11 {
+		t.Fatalf("cursor %d %v", height, ok)
+	}
+
+	// A new stream resumes from the cursor.
+	c.grow(1)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	blocks = newStream(c, cursor).Blocks(ctx)
+	for _, index := range []uint32{11, 12} {
+		if block := <-blocks; block == nil || block.Index != index {
+			t.Fatalf("resumed with %v, expected %d", block, index)
+		}
+	}
+}
+
+func TestStreamFailure(t *testing.T) {
+	c := &chain{count: 5, failures: map[uint32]int{3: 3}}
+	s := newStream(c, nil)
+	var got []uint32
+	for block := range s.Blocks(context.Background()) {
+		got = append(got, block.Index)
+	}
+	if len(got) != 3 || s.Err() == nil {
+		t.Fatalf("blocks %v, error %v", got, s.Err())
+	}
+}
+
+func TestFileCursor(t *testing.T) {
+	cursor := &FileCursor{Path: filepath.Join(t.TempDir(), "cursor")}
+	if _, ok, err := cursor.LoadCursor(); ok || err != nil {
+		t.Fatalf("empty cursor %v %v", ok, err)
+	}
+	if err := cursor.SaveCursor(2800000); err != nil {
+		t.Fatal(err)
+	}
+	if height, ok, err := cursor.LoadCursor(); !ok || err != nil || height != 2800000 {
+		t.Fatalf("cursor %d %v %v", height, ok, err)
+	}
+}
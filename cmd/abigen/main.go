@@ -0,0 +1,50 @@
+// Command abigen generates Go bindings for a NEO contract from its .abi.json file.
+//
+//	abigen -abi token.abi.json -pkg token -type Token -out token/token.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/abi"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func main() {
+	abiPath := flag.String("abi", "", "path to the contract .abi.json file")
+	pkg := flag.String("pkg", "", "package name of the generated code")
+	typeName := flag.String("type", "", "name of the generated binding type (default: the package name, capitalized)")
+	out := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if *abiPath == "" || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *typeName == "" {
+		*typeName = strings.ToUpper((*pkg)[:1]) + (*pkg)[1:]
+	}
+
+	a, err := abi.Load(*abiPath)
+	if err != nil {
+		fatal(err)
+	}
+	code, err := abi.Bind(a, *pkg, *typeName)
+	if err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "abigen:", err)
+	os.Exit(1)
+}
@@ -25,7 +25,7 @@ func NeoTransfer() (string, bool) {
 
 	params.Utxos = utxoList
 
-	raw, ok := neo.CreateContractTransaction(params)
+	_, raw, ok := neo.CreateContractTransaction(params)
 	println(raw, ok)
 
 	return raw, ok
@@ -59,9 +59,8 @@ func Nep5Transfer() (string, bool) {
 	return raw, ok
 }
 
-
 func NNSNameHash(domain string) string {
-	return ""
+	return utils.ToHexString(neo.NameHash(domain).Bytes())
 }
 
 func main() {
@@ -82,11 +81,11 @@ func main() {
 
 	Nep5Transfer()
 
-	b, err := neo.InvokeNNSScript("")
+	println(NNSNameHash("test.neo"))
+
+	b, err := neo.InvokeNNSScript("test.neo")
 	if err != nil {
 		panic(err)
 	}
 	println(utils.ToHexString(b))
-
-
 }
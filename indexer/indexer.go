@@ -0,0 +1,361 @@
+// Package indexer keeps the unspent outputs and NEP-5 balances of watched
+// addresses in an embedded bbolt database, fed with blocks from a node or
+// a chain dump.
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/blockstream"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	bolt "go.etcd.io/bbolt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	metaBucket = []byte("meta")
+	// watchBucket holds the script hashes of the watched addresses.
+	watchBucket = []byte("watched")
+	// outputBucket maps the outpoints of unspent outputs of watched
+	// addresses, txid|n, to their outputRecord.
+	outputBucket = []byte("outputs")
+	// unspentBucket indexes the same outputs by address|asset|txid|n.
+	unspentBucket = []byte("unspent")
+	// nep5Bucket maps address|contract to a NEP-5 balance.
+	nep5Bucket = []byte("nep5")
+
+	heightKey = []byte("height")
+)
+
+// outputRecord is an unspent output: address, asset, value and the index of
+// the block it was created in.
+type outputRecord []byte
+
+func newOutputRecord(address utils.Uint160, asset utils.Uint256, value uint64, height uint32) outputRecord {
+	r := make(outputRecord, 0, 64)
+	r = append(r, address.Bytes()...)
+	r = append(r, asset.Bytes()...)
+	r = binary.BigEndian.AppendUint64(r, value)
+	return binary.BigEndian.AppendUint32(r, height)
+}
+
+func (r outputRecord) address() []byte { return r[:20] }
+func (r outputRecord) asset() []byte   { return r[20:52] }
+func (r outputRecord) value() uint64   { return binary.BigEndian.Uint64(r[52:60]) }
+func (r outputRecord) height() uint32  { return binary.BigEndian.Uint32(r[60:64]) }
+
+func outpoint(txid utils.Uint256, n uint16) []byte {
+	return binary.BigEndian.AppendUint16(txid.Bytes(), n)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// Indexer follows the chain for a set of watched addresses. It only sees
+// what happens after an address is watched: watch addresses before indexing
+// the blocks they appear in. NEO 2.x blocks are final, so there is no
+// rollback.
+type Indexer struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the index database at path.
+func Open(path string) (*Indexer, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{metaBucket, watchBucket, outputBucket, unspentBucket, nep5Bucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Indexer{db: db}, nil
+}
+
+// Close closes the database.
+func (ix *Indexer) Close() error {
+	return ix.db.Close()
+}
+
+// Watch adds addresses to the watched set.
+func (ix *Indexer) Watch(addresses ...string) error {
+	var hashes []utils.Uint160
+	for _, address := range addresses {
+		hash, err := neo.AddressToScriptHash(address)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, hash)
+	}
+	return ix.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(watchBucket)
+		for _, hash := range hashes {
+			if err := b.Put(hash.Bytes(), []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Watched returns the watched addresses.
+func (ix *Indexer) Watched() ([]string, error) {
+	var addresses []string
+	err := ix.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(watchBucket).ForEach(func(k, v []byte) error {
+			hash, _ := utils.Uint160DecodeBytes(k)
+			addresses = append(addresses, neo.ScriptHashToAddress(hash))
+			return nil
+		})
+	})
+	return addresses, err
+}
+
+// Height returns the index of the next block to add.
+func (ix *Indexer) Height() (uint32, error) {
+	var height uint32
+	err := ix.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(metaBucket).Get(heightKey); v != nil {
+			height = binary.BigEndian.Uint32(v)
+		}
+		return nil
+	})
+	return height, err
+}
+
+// LoadCursor makes the indexer the blockstream.CursorStore of the stream
+// feeding it, so the stream resumes at the next block to add. A fresh index
+// holds no cursor, so the stream begins at its Start.
+func (ix *Indexer) LoadCursor() (uint32, bool, error) {
+	var height uint32
+	var ok bool
+	err := ix.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(metaBucket).Get(heightKey); v != nil {
+			height, ok = binary.BigEndian.Uint32(v), true
+		}
+		return nil
+	})
+	return height, ok, err
+}
+
+// SaveCursor implements blockstream.CursorStore. The height only moves as
+// blocks are added, so there is nothing to save.
+func (ix *Indexer) SaveCursor(height uint32) error {
+	return nil
+}
+
+// AddBlock applies block and the NEP-5 transfers of its transactions. The
+// first block added to a fresh index may be at any height; after that, a
+// block already added is ignored and a block past the next one is an error.
+func (ix *Indexer) AddBlock(block *neo.Block, transfers []rpc.Nep5TransferEvent) error {
+	return ix.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		height := block.Index
+		if v := meta.Get(heightKey); v != nil {
+			height = binary.BigEndian.Uint32(v)
+		}
+		if block.Index < height {
+			return nil
+		}
+		if block.Index > height {
+			return fmt.Errorf("block %d added before block %d", block.Index, height)
+		}
+
+		watched := tx.Bucket(watchBucket)
+		outputs := tx.Bucket(outputBucket)
+		unspent := tx.Bucket(unspentBucket)
+		for _, t := range block.Transactions {
+			for _, input := range t.GetInputs() {
+				key := outpoint(input.GetHash(), input.GetIndex())
+				record := outputRecord(outputs.Get(key))
+				if record == nil {
+					continue
+				}
+				if err := unspent.Delete(concat(record.address(), record.asset(), key)); err != nil {
+					return err
+				}
+				if err := outputs.Delete(key); err != nil {
+					return err
+				}
+			}
+
+			txid := t.GetTxId()
+			for i, output := range t.GetOutputs() {
+				address := output.GetScriptHash()
+				if watched.Get(address.Bytes()) == nil {
+					continue
+				}
+				key := outpoint(txid, uint16(i))
+				record := newOutputRecord(address, output.GetAssetId(), output.GetValue(), block.Index)
+				if err := outputs.Put(key, record); err != nil {
+					return err
+				}
+				if err := unspent.Put(concat(record.address(), record.asset(), key), []byte{}); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, transfer := range transfers {
+			if err := addTransfer(tx, transfer); err != nil {
+				return err
+			}
+		}
+
+		next := make([]byte, 4)
+		binary.BigEndian.PutUint32(next, block.Index+1)
+		return meta.Put(heightKey, next)
+	})
+}
+
+func addTransfer(tx *bolt.Tx, transfer rpc.Nep5TransferEvent) error {
+	watched := tx.Bucket(watchBucket)
+	balances := tx.Bucket(nep5Bucket)
+	for _, side := range []struct {
+		address string
+		sign    int
+	}{{transfer.From, -1}, {transfer.To, 1}} {
+		if side.address == "" {
+			continue
+		}
+		hash, err := neo.AddressToScriptHash(side.address)
+		if err != nil || watched.Get(hash.Bytes()) == nil {
+			continue
+		}
+		key := concat(hash.Bytes(), transfer.Contract.Bytes())
+		balance := utils.BytesToBigInt(balances.Get(key))
+		if side.sign < 0 {
+			balance.Sub(balance, transfer.Amount)
+		} else {
+			balance.Add(balance, transfer.Amount)
+		}
+		if err := balances.Put(key, utils.BigIntToBytes(balance)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUnspents returns the unspent outputs of address holding assetId, such
+// as neo.NeoAssetId, making the indexer a replacement for the UTXO lookups
+// of provider.UtxoProvider.
+func (ix *Indexer) GetUnspents(ctx context.Context, address, assetId string) ([]neo.Utxo, error) {
+	hash, err := neo.AddressToScriptHash(address)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := utils.Uint256DecodeString(strings.TrimPrefix(assetId, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos []neo.Utxo
+	err = ix.db.View(func(tx *bolt.Tx) error {
+		outputs := tx.Bucket(outputBucket)
+		prefix := concat(hash.Bytes(), asset.Bytes())
+		c := tx.Bucket(unspentBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			key := k[len(prefix):]
+			var txid utils.Uint256
+			copy(txid[:], key[:32])
+			utxos = append(utxos, neo.Utxo{
+				Hash:  txid.String(),
+				Value: outputRecord(outputs.Get(key)).value(),
+				N:     binary.BigEndian.Uint16(key[32:]),
+			})
+		}
+		return nil
+	})
+	return utxos, err
+}
+
+// GetNep5Balances returns the NEP-5 balances of address by contract.
+func (ix *Indexer) GetNep5Balances(address string) (map[utils.Uint160]*big.Int, error) {
+	hash, err := neo.AddressToScriptHash(address)
+	if err != nil {
+		return nil, err
+	}
+	balances := map[utils.Uint160]*big.Int{}
+	err = ix.db.View(func(tx *bolt.Tx) error {
+		prefix := hash.Bytes()
+		c := tx.Bucket(nep5Bucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			contract, _ := utils.Uint160DecodeBytes(k[len(prefix):])
+			balances[contract] = utils.BytesToBigInt(v)
+		}
+		return nil
+	})
+	return balances, err
+}
+
+// GetNep5Balance returns the balance of address in the token contract.
+func (ix *Indexer) GetNep5Balance(address string, contract utils.Uint160) (*big.Int, error) {
+	balances, err := ix.GetNep5Balances(address)
+	if err != nil {
+		return nil, err
+	}
+	if balance, ok := balances[contract]; ok {
+		return balance, nil
+	}
+	return new(big.Int), nil
+}
+
+// FetchTransfers returns the NEP-5 transfers of the invocation transactions
+// of block, from the application logs of the node.
+func FetchTransfers(ctx context.Context, c *rpc.Client, block *neo.Block) ([]rpc.Nep5TransferEvent, error) {
+	return c.GetBlockNep5Transfers(ctx, block)
+}
+
+// Sync indexes the blocks of the node c until ctx is done or fetching fails.
+func (ix *Indexer) Sync(ctx context.Context, c *rpc.Client) error {
+	stream := blockstream.New(c, ix)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for block := range stream.Blocks(ctx) {
+		transfers, err := FetchTransfers(ctx, c, block)
+		if err != nil {
+			return err
+		}
+		if err := ix.AddBlock(block, transfers); err != nil {
+			return err
+		}
+	}
+	return stream.Err()
+}
+
+// ImportDump indexes the blocks of a chain.acc dump. Dumps carry no
+// notifications, so NEP-5 balances are not updated.
+func (ix *Indexer) ImportDump(r io.Reader, hasStart bool) error {
+	dump, err := blockstream.NewDumpReader(r, hasStart)
+	if err != nil {
+		return err
+	}
+	for {
+		block, err := dump.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := ix.AddBlock(block, nil); err != nil {
+			return err
+		}
+	}
+}
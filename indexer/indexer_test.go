@@ -0,0 +1,218 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/blockstream"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	alice = "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7"
+	bob   = "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc"
+)
+
+// send returns a transaction from alice spending utxo, paying value to bob.
+func send(t *testing.T, utxo neo.Utxo, value uint64) *neo.Transaction {
+	params := &neo.CreateSignParams{
+		Version: 1,
+		PriKey:  "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1",
+		From:    alice,
+		To:      bob,
+		AssetId: neo.NeoAssetId,
+		Value:   value,
+		Utxos:   []neo.Utxo{utxo},
+	}
+	_, raw, err := neo.CreateTx(neo.ContractTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := hex.DecodeString(raw)
+	tx, err := neo.DecodeTransaction(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// chain returns two blocks: alice pays 1 NEO to bob out of 10, then 2 NEO
+// out of her change.
+func chain(t *testing.T) []*neo.Block {
+	first := send(t, neo.Utxo{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 10 * neo.D}, 1*neo.D)
+	second := send(t, neo.Utxo{Hash: first.GetTxId().String(), Value: 9 * neo.D, N: 1}, 2*neo.D)
+	var blocks []*neo.Block
+	for i, tx := range []*neo.Transaction{first, second} {
+		block := &neo.Block{Header: neo.Header{Index: uint32(i)}, Transactions: []*neo.Transaction{tx}}
+		block.RebuildMerkleRoot()
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func open(t *testing.T, path string) *Indexer {
+	ix, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Watch(alice, bob); err != nil {
+		t.Fatal(err)
+	}
+	return ix
+}
+
+func checkUnspents(t *testing.T, ix *Indexer, blocks []*neo.Block) {
+	first, second := blocks[0].Transactions[0].GetTxId().String(), blocks[1].Transactions[0].GetTxId().String()
+	expected := map[string][]neo.Utxo{
+		alice: {{Hash: second, Value: 7 * neo.D, N: 1}},
+		bob:   {{Hash: first, Value: 1 * neo.D}, {Hash: second, Value: 2 * neo.D}},
+	}
+	for address, utxos := range expected {
+		got, err := ix.GetUnspents(context.Background(), address, neo.NeoAssetId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The order of outputs follows their transaction hashes.
+		if len(got) == 2 && got[0].Hash != utxos[0].Hash {
+			got[0], got[1] = got[1], got[0]
+		}
+		if !reflect.DeepEqual(got, utxos) {
+			t.Fatalf("unspents of %s: %+v", address, got)
+		}
+	}
+	if gas, _ := ix.GetUnspents(context.Background(), bob, neo.GasAssetId); len(gas) != 0 {
+		t.Fatalf("GAS unspents %+v", gas)
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	ix := open(t, path)
+	blocks := chain(t)
+
+	token, _ := utils.Uint160DecodeString("ecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9")
+	transfers := []rpc.Nep5TransferEvent{
+		{Contract: token, To: alice, Amount: big.NewInt(100)},
+		{Contract: token, From: alice, To: bob, Amount: big.NewInt(30)},
+		{Contract: token, From: bob, To: "AK2nJJpJr6o664CWJKi1QRXjqeic2zRp8y", Amount: big.NewInt(5)},
+	}
+	if err := ix.AddBlock(blocks[0], nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.AddBlock(&neo.Block{Header: neo.Header{Index: 5}}, nil); err == nil {
+		t.Fatal("expected error for a block added out of order")
+	}
+	if err := ix.AddBlock(blocks[1], transfers); err != nil {
+		t.Fatal(err)
+	}
+	// Adding a block again changes nothing.
+	if err := ix.AddBlock(blocks[1], transfers); err != nil {
+		t.Fatal(err)
+	}
+	checkUnspents(t, ix, blocks)
+
+	if balance, _ := ix.GetNep5Balance(alice, token); balance.Int64() != 70 {
+		t.Fatalf("alice balance %s", balance)
+	}
+	if balance, _ := ix.GetNep5Balance(bob, token); balance.Int64() != 25 {
+		t.Fatalf("bob balance %s", balance)
+	}
+	if height, ok, err := ix.LoadCursor(); !ok || err != nil || height != 2 {
+		t.Fatalf("cursor %d %v %v", height, ok, err)
+	}
+
+	// The index survives a restart.
+	ix.Close()
+	ix = open(t, path)
+	defer ix.Close()
+	checkUnspents(t, ix, blocks)
+	if watched, _ := ix.Watched(); len(watched) != 2 {
+		t.Fatalf("watched %v", watched)
+	}
+}
+
+func TestImportDump(t *testing.T) {
+	blocks := chain(t)
+	dump := &bytes.Buffer{}
+	binary.Write(dump, binary.LittleEndian, uint32(len(blocks)))
+	for _, block := range blocks {
+		buf := &bytes.Buffer{}
+		block.Serialize(buf)
+		binary.Write(dump, binary.LittleEndian, uint32(buf.Len()))
+		dump.Write(buf.Bytes())
+	}
+
+	ix := open(t, filepath.Join(t.TempDir(), "index.db"))
+	defer ix.Close()
+	if err := ix.ImportDump(dump, false); err != nil {
+		t.Fatal(err)
+	}
+	checkUnspents(t, ix, blocks)
+}
+
+// source serves blocks from index start on.
+type source struct {
+	start  uint32
+	blocks [][]byte
+}
+
+func (s *source) GetBlockCount(ctx context.Context) (uint32, error) {
+	return s.start + uint32(len(s.blocks)), nil
+}
+
+func (s *source) GetRawBlock(ctx context.Context, index uint32) ([]byte, error) {
+	if index < s.start {
+		return nil, fmt.Errorf("block %d before %d", index, s.start)
+	}
+	return s.blocks[index-s.start], nil
+}
+
+func TestStreamStart(t *testing.T) {
+	ix := open(t, filepath.Join(t.TempDir(), "index.db"))
+	defer ix.Close()
+	if _, ok, err := ix.LoadCursor(); ok || err != nil {
+		t.Fatalf("cursor of a fresh index %v %v", ok, err)
+	}
+
+	blocks := chain(t)
+	src := &source{start: 5}
+	for i, block := range blocks {
+		block.Index = src.start + uint32(i)
+		buf := &bytes.Buffer{}
+		block.Serialize(buf)
+		src.blocks = append(src.blocks, buf.Bytes())
+	}
+	stream := blockstream.New(src, ix)
+	stream.Start = src.start
+	stream.PollInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next := src.start
+	for block := range stream.Blocks(ctx) {
+		if block.Index != next {
+			t.Fatalf("block %d, expected %d", block.Index, next)
+		}
+		if err := ix.AddBlock(block, nil); err != nil {
+			t.Fatal(err)
+		}
+		if next++; next == src.start+uint32(len(blocks)) {
+			cancel()
+		}
+	}
+	if next != src.start+uint32(len(blocks)) {
+		t.Fatalf("stream stopped at %d: %v", next, stream.Err())
+	}
+	checkUnspents(t, ix, blocks)
+	if height, ok, err := ix.LoadCursor(); !ok || err != nil || height != next {
+		t.Fatalf("cursor %d %v %v", height, ok, err)
+	}
+}
@@ -1,5 +1,10 @@
 package neo
 
+import (
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
 const (
 	SCRIPT_HASH_LENGTH   byte = 20
 	NEO_ADDRESS_VERSION  byte = 0x17
@@ -15,3 +20,18 @@ type Address struct {
 func (addr *Address) GetAddr() string {
 	return addr.Addr
 }
+
+// AddressToScriptHash returns the script hash an address encodes.
+func AddressToScriptHash(address string) (utils.Uint160, error) {
+	hash, ok := GetPublicKeyHashFromAddress(address)
+	if !ok {
+		return utils.Uint160{}, fmt.Errorf("invalid address %s", address)
+	}
+	return utils.Uint160DecodeBytes(utils.BytesReverse(hash))
+}
+
+// ScriptHashToAddress returns the address of a script hash.
+func ScriptHashToAddress(hash utils.Uint160) string {
+	address, _ := GetAddressFromScriptHash(hash.BytesReverse())
+	return address
+}
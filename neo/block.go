@@ -0,0 +1,158 @@
+package neo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// Header is a block without its transactions.
+type Header struct {
+	Version       uint32
+	PrevHash      utils.Uint256
+	MerkleRoot    utils.Uint256
+	Timestamp     uint32
+	Index         uint32
+	ConsensusData uint64
+	// NextConsensus is the script hash of the validators of the next block.
+	NextConsensus utils.Uint160
+	Witness       Witness
+}
+
+// Block is a header and the transactions it confirms, the miner transaction first.
+type Block struct {
+	Header
+	Transactions []*Transaction
+}
+
+// SerializeUnsigned writes the fields covered by the block hash.
+func (self *Header) SerializeUnsigned(buf *bytes.Buffer) {
+	utils.WriteUint32(buf, self.Version)
+	buf.Write(self.PrevHash.Bytes())
+	buf.Write(self.MerkleRoot.Bytes())
+	utils.WriteUint32(buf, self.Timestamp)
+	utils.WriteUint32(buf, self.Index)
+	utils.WriteUint64(buf, self.ConsensusData)
+	buf.Write(self.NextConsensus.BytesReverse())
+}
+
+func (self *Header) serializeSigned(buf *bytes.Buffer) {
+	self.SerializeUnsigned(buf)
+	buf.WriteByte(1)
+	writeWitness(buf, &self.Witness)
+}
+
+// Serialize writes the header as sent in a headers message, followed by an
+// empty transaction count.
+func (self *Header) Serialize(buf *bytes.Buffer) {
+	self.serializeSigned(buf)
+	buf.WriteByte(0)
+}
+
+func (self *Header) deserializeSigned(buf *bytes.Buffer) {
+	self.Version = binary.LittleEndian.Uint32(utils.ReadBytes(buf, 4))
+	copy(self.PrevHash[:], utils.ReadBytes(buf, 32))
+	copy(self.MerkleRoot[:], utils.ReadBytes(buf, 32))
+	self.Timestamp = binary.LittleEndian.Uint32(utils.ReadBytes(buf, 4))
+	self.Index = binary.LittleEndian.Uint32(utils.ReadBytes(buf, 4))
+	self.ConsensusData = binary.LittleEndian.Uint64(utils.ReadBytes(buf, 8))
+	self.NextConsensus, _ = utils.Uint160DecodeBytes(utils.BytesReverse(utils.ReadBytes(buf, 20)))
+	if n, _ := buf.ReadByte(); n != 1 {
+		panic("runtime error: header witness count error")
+	}
+	readWitness(buf, &self.Witness)
+}
+
+// Deserialize reads a header as sent in a headers message.
+func (self *Header) Deserialize(buf *bytes.Buffer) {
+	self.deserializeSigned(buf)
+	if n, _ := buf.ReadByte(); n != 0 {
+		panic("runtime error: header transaction count error")
+	}
+}
+
+// GetHash returns the block hash, the double SHA-256 of the unsigned header.
+func (self *Header) GetHash() utils.Uint256 {
+	buf := &bytes.Buffer{}
+	self.SerializeUnsigned(buf)
+	hash := sha256.Sum256(buf.Bytes())
+	hash = sha256.Sum256(hash[:])
+	return utils.Uint256(hash)
+}
+
+// GetHeader returns a copy of the header of the block.
+func (self *Block) GetHeader() *Header {
+	header := self.Header
+	return &header
+}
+
+// Serialize writes the block in the format of getblock and block messages.
+func (self *Block) Serialize(buf *bytes.Buffer) {
+	self.serializeSigned(buf)
+	utils.WriteVarInt(buf, uint64(len(self.Transactions)))
+	for _, tx := range self.Transactions {
+		tx.Serialize(buf)
+	}
+}
+
+// Deserialize reads a block, decoding every transaction it holds.
+func (self *Block) Deserialize(buf *bytes.Buffer) {
+	self.deserializeSigned(buf)
+	self.Transactions = make([]*Transaction, utils.ReadCount(buf, 65535))
+	for i := range self.Transactions {
+		tx := &Transaction{}
+		tx.deserialize(buf)
+		self.Transactions[i] = tx
+	}
+	if buf.Len() > 0 {
+		panic("len error")
+	}
+}
+
+// DecodeBlock decodes a serialized block.
+func DecodeBlock(data []byte) (block *Block, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			block, err = nil, fmt.Errorf("invalid block: %v", r)
+		}
+	}()
+
+	block = &Block{}
+	block.Deserialize(bytes.NewBuffer(data))
+	return block, nil
+}
+
+// DecodeHeader decodes a header serialized as in a headers message.
+func DecodeHeader(data []byte) (header *Header, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			header, err = nil, fmt.Errorf("invalid header: %v", r)
+		}
+	}()
+
+	buf := bytes.NewBuffer(data)
+	header = &Header{}
+	header.Deserialize(buf)
+	if buf.Len() > 0 {
+		panic("len error")
+	}
+	return header, nil
+}
+
+func writeWitness(buf *bytes.Buffer, w *Witness) {
+	utils.WriteVarInt(buf, uint64(len(w.InvocationScript)))
+	buf.Write(w.InvocationScript)
+	utils.WriteVarInt(buf, uint64(len(w.VerificationScript)))
+	buf.Write(w.VerificationScript)
+}
+
+func readWitness(buf *bytes.Buffer, w *Witness) {
+	w.InvocationScript = utils.ReadVarBytes(buf, 65535)
+	w.VerificationScript = utils.ReadVarBytes(buf, 65535)
+}
+
+func readVarString(buf *bytes.Buffer, max uint64) string {
+	return string(utils.ReadVarBytes(buf, max))
+}
@@ -0,0 +1,135 @@
+package neo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"testing"
+)
+
+func TestBlock(t *testing.T) {
+	miner, err := DecodeTransaction([]byte{MinerTransaction, 0, 0xd2, 0x04, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := &CreateSignParams{
+		Version: 1,
+		PriKey:  "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1",
+		From:    "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+		To:      "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc",
+		AssetId: NeoAssetId,
+		Value:   1 * D,
+		Utxos:   []Utxo{{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 10 * D}},
+	}
+	_, raw, err := CreateTx(ContractTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := hex.DecodeString(raw)
+	contract, err := DecodeTransaction(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev, _ := utils.Uint256DecodeString("d42561e3d30e15be6400b6df2f328e02d2bf6354c41dce433bc57687c82144bf")
+	next, _ := AddressToScriptHash("ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7")
+	block := &Block{
+		Header: Header{
+			PrevHash:      prev,
+			Timestamp:     1468595301,
+			Index:         1,
+			ConsensusData: 2083236893,
+			NextConsensus: next,
+			Witness:       Witness{InvocationScript: []byte{0x00}, VerificationScript: []byte{0x51}},
+		},
+		Transactions: []*Transaction{miner, contract},
+	}
+	buf := &bytes.Buffer{}
+	block.Serialize(buf)
+
+	decoded, err := DecodeBlock(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.GetHash().Equals(block.GetHash()) || decoded.NextConsensus != next || !decoded.PrevHash.Equals(prev) {
+		t.Fatalf("decoded header %+v", decoded.Header)
+	}
+	if len(decoded.Transactions) != 2 || decoded.Transactions[0].GetType() != MinerTransaction ||
+		!decoded.Transactions[1].GetTxId().Equals(contract.GetTxId()) {
+		t.Fatal("decoded transactions differ")
+	}
+	again := &bytes.Buffer{}
+	decoded.Serialize(again)
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Fatal("block does not serialize back to the same bytes")
+	}
+
+	// The hash covers the unsigned header only.
+	decoded.Witness = Witness{}
+	if !decoded.GetHash().Equals(block.GetHash()) {
+		t.Fatal("witness changed the block hash")
+	}
+	decoded.Index++
+	if decoded.GetHash().Equals(block.GetHash()) {
+		t.Fatal("index did not change the block hash")
+	}
+
+	if _, err := DecodeBlock(append(buf.Bytes(), 0)); err == nil {
+		t.Fatal("expected error for trailing data")
+	}
+	if _, err := DecodeBlock(buf.Bytes()[:60]); err == nil {
+		t.Fatal("expected error for truncated block")
+	}
+
+	headerBuf := &bytes.Buffer{}
+	block.GetHeader().Serialize(headerBuf)
+	header, err := DecodeHeader(headerBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !header.GetHash().Equals(block.GetHash()) || header.Timestamp != block.Timestamp {
+		t.Fatalf("decoded header %+v", header)
+	}
+}
+
+// genesisBlock is getblock 0 of the NEO 2.x main net: the miner transaction,
+// the registration of NEO and GAS and the issue of NEO.
+const genesisBlock = "000000000000000000000000000000000000000000000000000000000000000000000000f41bc036e39b0d6b0579c851c6fde83af802fa4e57bec0bc3365eae3abf43f8065fc8857000000001dac2b7c0000000059e75d652b5d3827bf04c165bbe9ef95cca4bf55010001510400001dac2b7c00000000400000455b7b226c616e67223a227a682d434e222c226e616d65223a22e5b08fe89a81e882a1227d2c7b226c616e67223a22656e222c226e616d65223a22416e745368617265227d5d0000c16ff28623000000da1745e9b549bd0bfa1a569971c77eba30cd5a4b00000000400001445b7b226c616e67223a227a682d434e222c226e616d65223a22e5b08fe89a81e5b881227d2c7b226c616e67223a22656e222c226e616d65223a22416e74436f696e227d5d0000c16ff286230008009f7fd096d37ed2c0e3f7f0cfc924beef4ffceb680000000001000000019b7cffdaa674beae0f930ebe6085af9093e5fe56b34a5c220ccdcf6efc336fc50000c16ff28623005fa99d93303775fe50ca119c327759313eccfa1c01000151"
+
+func TestGenesisBlock(t *testing.T) {
+	data, _ := hex.DecodeString(genesisBlock)
+	block, err := DecodeBlock(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.GetHash().String() != "d42561e3d30e15be6400b6df2f328e02d2bf6354c41dce433bc57687c82144bf" ||
+		!block.ComputeMerkleRoot().Equals(block.MerkleRoot) || len(block.Transactions) != 4 {
+		t.Fatalf("genesis block %+v", block.Header)
+	}
+	for i, c := range []struct {
+		id        string
+		assetType byte
+		precision byte
+	}{{NeoAssetId, GoverningToken, 0}, {GasAssetId, UtilityToken, 8}} {
+		tx := block.Transactions[i+1]
+		data, ok := tx.GetExtData().(*RegisterTransData)
+		if !ok || tx.GetTxId().String() != c.id || data.AssetType != c.assetType || data.Precision != c.precision ||
+			data.Amount != int64(100000000*D) || !bytes.Equal(data.Owner, []byte{0x00}) || tx.GetSystemFee() != 0 {
+			t.Fatalf("register transaction %s %+v", tx.GetTxId(), data)
+		}
+	}
+	if block.Transactions[3].GetType() != IssueTransaction || block.Transactions[3].GetOutputs()[0].GetAssetId().String() != NeoAssetId {
+		t.Fatal("genesis issue transaction")
+	}
+
+	buf := &bytes.Buffer{}
+	block.Serialize(buf)
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("genesis block does not serialize back to the same bytes")
+	}
+	for n := range data {
+		if _, err := DecodeBlock(data[:n]); err == nil {
+			t.Fatalf("expected error for the block truncated to %d bytes", n)
+		}
+	}
+}
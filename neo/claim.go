@@ -0,0 +1,65 @@
+package neo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"strings"
+)
+
+// setClaims makes self claim the GAS generated by params.Claims, paid in a
+// single output to params.To, or to params.From when To is empty.
+func (self *Transaction) setClaims(params *CreateSignParams) error {
+	if len(params.Claims) == 0 {
+		return errors.New("no claims")
+	}
+
+	type reference struct {
+		hash string
+		n    uint16
+	}
+	seen := make(map[reference]bool)
+	data := &ClaimTransData{}
+	var sum uint64
+	for _, claim := range params.Claims {
+		hash := strings.TrimPrefix(claim.Hash, "0x")
+		txid, ok := utils.ToBytes(hash)
+		if !ok || len(txid) != 32 {
+			return fmt.Errorf("invalid claim %s", claim.Hash)
+		}
+		if seen[reference{hash, claim.N}] {
+			return fmt.Errorf("output %s:%d claimed twice", hash, claim.N)
+		}
+		seen[reference{hash, claim.N}] = true
+		data.claims = append(data.claims, TransactionInput{
+			hash:  utils.BytesReverse(txid),
+			index: claim.N,
+		})
+		sum += claim.Value
+	}
+	if sum == 0 {
+		return errors.New("no GAS to claim")
+	}
+
+	to := params.To
+	if to == "" {
+		to = params.From
+	}
+	pubkeyhash, ok := getPublicKeyHashFromAddress(to)
+	if !ok {
+		return fmt.Errorf("invalid address %s", to)
+	}
+	gasId, _ := utils.ToBytes(GasAssetId)
+	output := TransactionOutput{
+		assetId:   utils.BytesReverse(gasId),
+		toAddress: pubkeyhash,
+	}
+	output.value.value = sum
+
+	// Claim transactions only exist in version 0.
+	self.version = 0
+	self.extdata = data
+	self.inputs = nil
+	self.outputs = []TransactionOutput{output}
+	return nil
+}
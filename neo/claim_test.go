@@ -0,0 +1,54 @@
+package neo
+
+import (
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"testing"
+)
+
+func TestCreateClaimTransaction(t *testing.T) {
+	params := &CreateSignParams{
+		Version: 1,
+		PriKey:  "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1",
+		From:    "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+		Claims: []Utxo{
+			{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 812345, N: 1},
+			{Hash: "0xd233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 2 * D},
+		},
+	}
+	_, raw, err := CreateTx(ClaimTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := utils.ToBytes(raw)
+	tx, err := DecodeTransaction(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := tx.GetClaims()
+	if tx.GetType() != ClaimTransaction || tx.GetVersion() != 0 || len(claims) != 2 || len(tx.GetInputs()) != 0 {
+		t.Fatalf("claim transaction %x", data)
+	}
+	if claims[0].GetHash().String() != params.Claims[0].Hash || claims[0].GetIndex() != 1 ||
+		claims[1].GetHash().String() != params.Claims[1].Hash[2:] || claims[1].GetIndex() != 0 {
+		t.Fatalf("claims %+v", claims)
+	}
+	outputs := tx.GetOutputs()
+	if len(outputs) != 1 || outputs[0].GetAssetId().String() != GasAssetId || outputs[0].GetValue() != 2*D+812345 ||
+		ScriptHashToAddress(outputs[0].GetScriptHash()) != params.From {
+		t.Fatalf("claim outputs %+v", outputs)
+	}
+	hash, _ := AddressToScriptHash(params.From)
+	if err := VerifyWitness(tx, hash, &tx.GetWitnesses()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	params.Claims = append(params.Claims, params.Claims[0])
+	if _, err := BuildTx(ClaimTransaction, params); err == nil {
+		t.Fatal("expected error for an output claimed twice")
+	}
+	params.Claims = nil
+	if _, err := BuildTx(ClaimTransaction, params); err == nil {
+		t.Fatal("expected error without claims")
+	}
+}
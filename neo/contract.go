@@ -0,0 +1,256 @@
+package neo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"io/ioutil"
+	"math/big"
+	"strings"
+)
+
+// ContractParameterType is the type of a contract parameter or return value.
+type ContractParameterType byte
+
+const (
+	SignatureType        ContractParameterType = 0x00
+	BooleanType          ContractParameterType = 0x01
+	IntegerType          ContractParameterType = 0x02
+	Hash160Type          ContractParameterType = 0x03
+	Hash256Type          ContractParameterType = 0x04
+	ByteArrayType        ContractParameterType = 0x05
+	PublicKeyType        ContractParameterType = 0x06
+	StringType           ContractParameterType = 0x07
+	ArrayType            ContractParameterType = 0x10
+	MapType              ContractParameterType = 0x12
+	InteropInterfaceType ContractParameterType = 0xf0
+	VoidType             ContractParameterType = 0xff
+)
+
+var contractParameterTypeNames = map[ContractParameterType]string{
+	SignatureType:        "Signature",
+	BooleanType:          "Boolean",
+	IntegerType:          "Integer",
+	Hash160Type:          "Hash160",
+	Hash256Type:          "Hash256",
+	ByteArrayType:        "ByteArray",
+	PublicKeyType:        "PublicKey",
+	StringType:           "String",
+	ArrayType:            "Array",
+	MapType:              "Map",
+	InteropInterfaceType: "InteropInterface",
+	VoidType:             "Void",
+}
+
+func (t ContractParameterType) String() string {
+	if name, ok := contractParameterTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(t))
+}
+
+// ParseContractParameterType parses a type name as the NEO compiler writes
+// it in .abi.json files, such as "Hash160" or "ByteArray".
+func ParseContractParameterType(name string) (ContractParameterType, error) {
+	for t, n := range contractParameterTypeNames {
+		if strings.EqualFold(n, name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown contract parameter type %s", name)
+}
+
+// DeployParams describes a contract to deploy, or to migrate a contract to.
+type DeployParams struct {
+	Script        []byte
+	ParameterList []ContractParameterType
+	ReturnType    ContractParameterType
+
+	NeedStorage   bool
+	DynamicInvoke bool
+	Payable       bool
+
+	Name        string
+	Version     string
+	Author      string
+	Email       string
+	Description string
+}
+
+// ReadAVM reads a compiled contract file.
+func ReadAVM(path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+// Properties returns the contract property flags.
+func (p *DeployParams) Properties() byte {
+	var properties byte
+	if p.NeedStorage {
+		properties |= vm.HasStorage
+	}
+	if p.DynamicInvoke {
+		properties |= vm.HasDynamicInvoke
+	}
+	if p.Payable {
+		properties |= vm.Payable
+	}
+	return properties
+}
+
+// ScriptHash returns the script hash the contract is deployed at.
+func (p *DeployParams) ScriptHash() utils.Uint160 {
+	return vm.ScriptHash(p.Script)
+}
+
+func (p *DeployParams) validate() error {
+	if len(p.Script) == 0 || len(p.Script) > vm.MaxItemSize {
+		return errors.New("invalid contract script size")
+	}
+	if len(p.ParameterList) > 252 {
+		return errors.New("too many contract parameters")
+	}
+	for _, s := range []string{p.Name, p.Version, p.Author, p.Email} {
+		if len(s) > 252 {
+			return errors.New("contract metadata too long")
+		}
+	}
+	if len(p.Description) > 65536 {
+		return errors.New("contract description too long")
+	}
+	return nil
+}
+
+// args returns the Contract.Create arguments, the script first.
+func (p *DeployParams) args() []interface{} {
+	paramList := make([]byte, len(p.ParameterList))
+	for i, t := range p.ParameterList {
+		paramList[i] = byte(t)
+	}
+	return []interface{}{
+		p.Script,
+		paramList,
+		big.NewInt(int64(p.ReturnType)),
+		big.NewInt(int64(p.Properties())),
+		p.Name,
+		p.Version,
+		p.Author,
+		p.Email,
+		p.Description,
+	}
+}
+
+func emitArg(sb *ScriptBuilder, arg interface{}) {
+	switch v := arg.(type) {
+	case []byte:
+		sb.EmitPushBytes(v)
+	case string:
+		sb.EmitPushString(v)
+	case *big.Int:
+		sb.EmitPushNumber(*v)
+	}
+}
+
+// CreateDeployScript returns the script calling Neo.Contract.Create for p.
+func CreateDeployScript(p *DeployParams) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	sb := &ScriptBuilder{}
+	args := p.args()
+	for i := len(args) - 1; i >= 0; i-- {
+		emitArg(sb, args[i])
+	}
+	sb.EmitSysCall("Neo.Contract.Create")
+	return sb.ToBytes(), nil
+}
+
+// CreateMigrateScript returns the script invoking operation of contract with
+// the Neo.Contract.Migrate arguments for p packed in an array. Only the
+// contract itself can migrate, so operation is expected to pass them on.
+func CreateMigrateScript(contract utils.Uint160, operation string, p *DeployParams) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	sb := &ScriptBuilder{}
+	args := p.args()
+	for i := len(args) - 1; i >= 0; i-- {
+		emitArg(sb, args[i])
+	}
+	sb.EmitPushNumber(*big.NewInt(int64(len(args))))
+	sb.Emit(opcode.PACK, nil)
+	sb.EmitPushString(operation)
+	sb.EmitAppCall(contract.BytesReverse(), false)
+	return sb.ToBytes(), nil
+}
+
+// DeployFee returns the system fee, in Fixed8 units, of deploying p.
+func DeployFee(p *DeployParams) (uint64, error) {
+	script, err := CreateDeployScript(p)
+	if err != nil {
+		return 0, err
+	}
+	consumed, err := vm.EstimateGas(script)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(vm.SystemFee(consumed)), nil
+}
+
+// MigrateFee returns the system fee, in Fixed8 units, of migrating a contract
+// to p through CreateMigrateScript. It adds the Contract.Migrate price to the
+// invocation script, as the syscall runs inside the migrated contract.
+func MigrateFee(contract utils.Uint160, operation string, p *DeployParams) (uint64, error) {
+	script, err := CreateMigrateScript(contract, operation, p)
+	if err != nil {
+		return 0, err
+	}
+	consumed, err := vm.EstimateGas(script)
+	if err != nil {
+		return 0, err
+	}
+	consumed += vm.ContractPrice(p.Properties()) * vm.GasRatio
+	return uint64(vm.SystemFee(consumed)), nil
+}
+
+// CreateDeployTransaction returns the unsigned invocation transaction
+// deploying p. The system fee is paid from utxos, GAS unspents of from, and
+// the change goes back to from.
+func CreateDeployTransaction(p *DeployParams, from string, utxos []Utxo) (*Transaction, error) {
+	script, err := CreateDeployScript(p)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := DeployFee(p)
+	if err != nil {
+		return nil, err
+	}
+	return createFeeTransaction(script, fee, from, utxos)
+}
+
+// CreateMigrateTransaction returns the unsigned invocation transaction
+// migrating contract to p. The system fee is paid as for CreateDeployTransaction.
+func CreateMigrateTransaction(contract utils.Uint160, operation string, p *DeployParams, from string, utxos []Utxo) (*Transaction, error) {
+	script, err := CreateMigrateScript(contract, operation, p)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := MigrateFee(contract, operation, p)
+	if err != nil {
+		return nil, err
+	}
+	return createFeeTransaction(script, fee, from, utxos)
+}
+
+func createFeeTransaction(script []byte, fee uint64, from string, utxos []Utxo) (*Transaction, error) {
+	params := &CreateSignParams{
+		Version: 1,
+		From:    from,
+		AssetId: GasAssetId,
+		Data:    script,
+		Utxos:   utxos,
+		Gas:     fee,
+	}
+	return BuildTx(InvocationTransaction, params)
+}
@@ -0,0 +1,69 @@
+package neo
+
+import (
+	"bytes"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"testing"
+)
+
+func TestCreateDeployTransaction(t *testing.T) {
+	p := &DeployParams{
+		Script:        []byte{0x51, 0x66},
+		ParameterList: []ContractParameterType{StringType, ArrayType},
+		ReturnType:    ByteArrayType,
+		NeedStorage:   true,
+		Name:          "test",
+		Version:       "1.0",
+		Author:        "author",
+		Email:         "author@example.com",
+		Description:   "a test contract",
+	}
+
+	script, err := CreateDeployScript(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args []vm.StackItem
+	e := vm.NewEngine(vm.Application, nil)
+	e.Register("Neo.Contract.Create", func(e *vm.Engine) error {
+		for i := 0; i < 9; i++ {
+			args = append(args, e.Pop())
+		}
+		return nil
+	})
+	if result := e.Run(script); result.State != vm.HALT {
+		t.Fatalf("deploy script %s: %v", result.State, result.Err)
+	}
+	if !bytes.Equal(args[0].GetByteArray(), p.Script) ||
+		!bytes.Equal(args[1].GetByteArray(), []byte{0x07, 0x10}) ||
+		args[2].GetBigInteger().Int64() != int64(ByteArrayType) ||
+		args[3].GetBigInteger().Int64() != int64(vm.HasStorage) ||
+		string(args[8].GetByteArray()) != p.Description {
+		t.Fatal("deploy script arguments error")
+	}
+
+	fee, err := DeployFee(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != 490*D {
+		t.Fatalf("deploy fee %d", fee)
+	}
+
+	utxos := []Utxo{{Hash: "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 500 * D}}
+	tx, err := CreateDeployTransaction(p, "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7", utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.outputs) != 1 || tx.outputs[0].value.value != 10*D {
+		t.Fatal("deploy transaction change error")
+	}
+	if err := tx.Sign("L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1"); err != nil {
+		t.Fatal(err)
+	}
+
+	utxos[0].Value = 100 * D
+	if _, err := CreateDeployTransaction(p, "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7", utxos); err == nil {
+		t.Fatal("expected insufficient GAS error")
+	}
+}
@@ -0,0 +1,163 @@
+package neo
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DecrementInterval is the number of blocks after which the GAS generated
+// by a block decreases.
+const DecrementInterval = 2000000
+
+// GenerationAmount is the GAS each block generates, in whole GAS, during
+// the successive decrement intervals. Blocks past the last interval only
+// distribute system fees.
+var GenerationAmount = []uint64{8, 7, 6, 5, 4, 3, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+
+// GetSystemFee returns the system fee of the transaction, in units of 10^-8 GAS.
+func (self *Transaction) GetSystemFee() uint64 {
+	switch self.txtype {
+	case InvocationTransaction:
+		return self.GetGas()
+	case EnrollmentTransaction:
+		return 1000 * D
+	case PublishTransaction:
+		return 500 * D
+	case RegisterTransaction:
+		if data, ok := self.extdata.(*RegisterTransData); ok && (data.AssetType == GoverningToken || data.AssetType == UtilityToken) {
+			return 0
+		}
+		return 10000 * D
+	case IssueTransaction:
+		if self.version >= 1 {
+			return 0
+		}
+		for _, output := range self.outputs {
+			if id := output.GetAssetId().String(); id != NeoAssetId && id != GasAssetId {
+				return 500 * D
+			}
+		}
+	}
+	return 0
+}
+
+// SysFeeSource returns the sum of the system fees, in whole GAS, of the
+// blocks up to index included, as the getblocksysfee RPC does.
+type SysFeeSource interface {
+	GetSysFeeAmount(index uint32) (uint64, error)
+}
+
+// SysFeeTable is a SysFeeSource filled from blocks, such as those of a
+// chain dump, so that no remote service has to be trusted.
+type SysFeeTable struct {
+	mu      sync.RWMutex
+	amounts []uint64
+}
+
+// AddBlock adds the system fees of block, which must be the next one.
+func (t *SysFeeTable) AddBlock(block *Block) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if int(block.Index) != len(t.amounts) {
+		return fmt.Errorf("block %d added before block %d", block.Index, len(t.amounts))
+	}
+	var fee uint64
+	for _, tx := range block.Transactions {
+		fee += tx.GetSystemFee()
+	}
+	amount := fee / D
+	if len(t.amounts) > 0 {
+		amount += t.amounts[len(t.amounts)-1]
+	}
+	t.amounts = append(t.amounts, amount)
+	return nil
+}
+
+// GetSysFeeAmount implements SysFeeSource.
+func (t *SysFeeTable) GetSysFeeAmount(index uint32) (uint64, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if int(index) >= len(t.amounts) {
+		return 0, fmt.Errorf("no system fee for block %d", index)
+	}
+	return t.amounts[index], nil
+}
+
+// GasCoin is a NEO output generating GAS from StartHeight, the index of
+// the block creating it, to EndHeight, the index of the block spending it.
+type GasCoin struct {
+	// Value is the amount of NEO, in units of 10^-8 like Utxo.Value.
+	Value       uint64
+	StartHeight uint32
+	EndHeight   uint32
+}
+
+// GeneratedGas returns the GAS one NEO is given by the blocks from start to
+// end, end excluded, in units of 10^-8 GAS, system fees excluded.
+func GeneratedGas(start, end uint32) uint64 {
+	var amount uint64
+	ustart := start / DecrementInterval
+	if ustart >= uint32(len(GenerationAmount)) {
+		return 0
+	}
+	istart := start % DecrementInterval
+	uend := end / DecrementInterval
+	iend := end % DecrementInterval
+	if uend >= uint32(len(GenerationAmount)) {
+		uend = uint32(len(GenerationAmount))
+		iend = 0
+	}
+	if iend == 0 {
+		uend--
+		iend = DecrementInterval
+	}
+	for ; ustart < uend; ustart++ {
+		amount += uint64(DecrementInterval-istart) * GenerationAmount[ustart]
+		istart = 0
+	}
+	return amount + uint64(iend-istart)*GenerationAmount[ustart]
+}
+
+// Bonus returns the GAS generated for the coin and its share of the system
+// fees, in units of 10^-8 GAS, computed as NEO 2 nodes do.
+func (c *GasCoin) Bonus(fees SysFeeSource) (generated, sysFee uint64, err error) {
+	if c.EndHeight <= c.StartHeight {
+		return 0, 0, fmt.Errorf("coin held from block %d to %d", c.StartHeight, c.EndHeight)
+	}
+	end, err := fees.GetSysFeeAmount(c.EndHeight - 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	var start uint64
+	if c.StartHeight > 0 {
+		if start, err = fees.GetSysFeeAmount(c.StartHeight - 1); err != nil {
+			return 0, 0, err
+		}
+	}
+	neo := c.Value / D
+	return neo * GeneratedGas(c.StartHeight, c.EndHeight), neo * (end - start), nil
+}
+
+// CalculateUnclaimed returns the GAS that can be claimed for the spent
+// coins, available, and the GAS the unspent ones have generated so far,
+// unavailable until they are spent, in units of 10^-8 GAS. Unspent coins
+// are held until count, the number of blocks in the chain, whatever their
+// EndHeight.
+func CalculateUnclaimed(spent, unspent []GasCoin, count uint32, fees SysFeeSource) (available, unavailable uint64, err error) {
+	for _, c := range spent {
+		generated, sysFee, err := c.Bonus(fees)
+		if err != nil {
+			return 0, 0, err
+		}
+		available += generated + sysFee
+	}
+	for _, c := range unspent {
+		c.EndHeight = count
+		generated, sysFee, err := c.Bonus(fees)
+		if err != nil {
+			return 0, 0, err
+		}
+		unavailable += generated + sysFee
+	}
+	return available, unavailable, nil
+}
@@ -0,0 +1,65 @@
+package neo
+
+import (
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"testing"
+)
+
+func TestGeneratedGas(t *testing.T) {
+	for _, c := range []struct {
+		start, end uint32
+		amount     uint64
+	}{
+		{0, 1, 8},
+		{0, DecrementInterval, 8 * DecrementInterval},
+		{DecrementInterval - 1, DecrementInterval + 1, 8 + 7},
+		{DecrementInterval, 3 * DecrementInterval, (7 + 6) * DecrementInterval},
+		{22*DecrementInterval - 1, 22*DecrementInterval + 10, 1},
+		{22 * DecrementInterval, 23 * DecrementInterval, 0},
+	} {
+		if amount := GeneratedGas(c.start, c.end); amount != c.amount {
+			t.Fatalf("GAS generated from %d to %d: %d, expected %d", c.start, c.end, amount, c.amount)
+		}
+	}
+}
+
+func TestCalculateUnclaimed(t *testing.T) {
+	invocation := func(gas uint64) *Transaction {
+		return &Transaction{txtype: InvocationTransaction, version: 1, extdata: &InvokeTransData{gas: Fixed8{gas}}}
+	}
+	gasId, _ := utils.ToBytes(GasAssetId)
+	issue := &Transaction{txtype: IssueTransaction, outputs: []TransactionOutput{{assetId: utils.BytesReverse(gasId)}}}
+	publish := &Transaction{txtype: PublishTransaction}
+
+	fees := &SysFeeTable{}
+	for i, txs := range [][]*Transaction{
+		{invocation(2 * D)},
+		{issue},
+		{publish, invocation(D + D/2)},
+	} {
+		if err := fees.AddBlock(&Block{Header: Header{Index: uint32(i)}, Transactions: txs}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fees.AddBlock(&Block{Header: Header{Index: 5}}); err == nil {
+		t.Fatal("expected error for a block added out of order")
+	}
+	if amount, _ := fees.GetSysFeeAmount(2); amount != 503 {
+		t.Fatalf("system fees %d", amount)
+	}
+
+	coin := &GasCoin{Value: 10 * D, StartHeight: 1, EndHeight: 3}
+	if generated, sysFee, err := coin.Bonus(fees); err != nil || generated != 160 || sysFee != 5010 {
+		t.Fatalf("bonus %d %d %v", generated, sysFee, err)
+	}
+
+	spent := []GasCoin{{Value: 10 * D, StartHeight: 0, EndHeight: 2}}
+	unspent := []GasCoin{{Value: 5 * D, StartHeight: 1}}
+	available, unavailable, err := CalculateUnclaimed(spent, unspent, 3, fees)
+	if err != nil || available != 180 || unavailable != 2585 {
+		t.Fatalf("unclaimed %d %d %v", available, unavailable, err)
+	}
+	if _, _, err := CalculateUnclaimed(spent, unspent, 4, fees); err == nil {
+		t.Fatal("expected error past the system fees of the table")
+	}
+}
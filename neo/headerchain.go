@@ -0,0 +1,163 @@
+package neo
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// CreateMultiSigScript returns the verification script of an account
+// needing m signatures of pubkeys, the keys sorted as NEO sorts validators.
+func CreateMultiSigScript(m int, pubkeys []*ecdsa.PublicKey) ([]byte, error) {
+	if m < 1 || m > len(pubkeys) || len(pubkeys) > 1024 {
+		return nil, fmt.Errorf("invalid multisig %d of %d", m, len(pubkeys))
+	}
+	sorted := append([]*ecdsa.PublicKey(nil), pubkeys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].X.Cmp(sorted[j].X); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Y.Cmp(sorted[j].Y) < 0
+	})
+
+	sb := &ScriptBuilder{}
+	sb.EmitPushNumber(*big.NewInt(int64(m)))
+	for _, pubkey := range sorted {
+		sb.EmitPushBytes(CompressPublicKey(pubkey))
+	}
+	sb.EmitPushNumber(*big.NewInt(int64(len(sorted))))
+	sb.Emit(opcode.CHECKMULTISIG, nil)
+	return sb.ToBytes(), nil
+}
+
+// VerifyWitness checks that the verification script of w hashes to hash and
+// that running it after the invocation script accepts the message of container.
+func VerifyWitness(container vm.ScriptContainer, hash utils.Uint160, w *Witness) error {
+	if len(w.VerificationScript) == 0 {
+		return errors.New("empty verification script")
+	}
+	if scriptHash := vm.ScriptHash(w.VerificationScript); !scriptHash.Equals(hash) {
+		return fmt.Errorf("witness script hash %s, expected %s", scriptHash, hash)
+	}
+
+	e := vm.NewEngine(vm.Verification, container)
+	e.GasLimit = vm.GasFree
+	e.LoadScript(w.VerificationScript, -1)
+	e.LoadScript(w.InvocationScript, -1)
+	if e.Execute()&vm.FAULT != 0 {
+		return fmt.Errorf("witness verification faulted: %v", e.Err())
+	}
+	results := e.ResultStack()
+	if results.Count() != 1 || !results.Pop().GetBoolean() {
+		return errors.New("witness verification failed")
+	}
+	return nil
+}
+
+// GetMessage returns the data signed by the validators, making a header a
+// vm.ScriptContainer.
+func (self *Header) GetMessage() ([]byte, bool) {
+	buf := &bytes.Buffer{}
+	self.SerializeUnsigned(buf)
+	return buf.Bytes(), true
+}
+
+// Verify checks that the header follows prev: it links to it and carries
+// the signatures of the validators prev designated in NextConsensus.
+func (self *Header) Verify(prev *Header) error {
+	if self.Index != prev.Index+1 {
+		return fmt.Errorf("header %d does not follow %d", self.Index, prev.Index)
+	}
+	if !self.PrevHash.Equals(prev.GetHash()) {
+		return fmt.Errorf("header %d does not link to %s", self.Index, prev.GetHash())
+	}
+	if self.Timestamp <= prev.Timestamp {
+		return fmt.Errorf("header %d is not later than its previous header", self.Index)
+	}
+	if err := VerifyWitness(self, prev.NextConsensus, &self.Witness); err != nil {
+		return fmt.Errorf("header %d: %v", self.Index, err)
+	}
+	return nil
+}
+
+// HeaderChain is a chain of verified headers starting from a trusted one,
+// such as the genesis block or a checkpoint.
+type HeaderChain struct {
+	mu      sync.RWMutex
+	headers []*Header
+	hashes  map[utils.Uint256]uint32
+}
+
+// NewHeaderChain returns a chain starting at the trusted header.
+func NewHeaderChain(trusted *Header) *HeaderChain {
+	return &HeaderChain{
+		headers: []*Header{trusted},
+		hashes:  map[utils.Uint256]uint32{trusted.GetHash(): trusted.Index},
+	}
+}
+
+// Add verifies headers in order and appends them to the chain. Headers
+// already in the chain are skipped; it stops at the first invalid header.
+func (c *HeaderChain) Add(headers ...*Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, header := range headers {
+		if _, ok := c.hashes[header.GetHash()]; ok {
+			continue
+		}
+		if err := header.Verify(c.headers[len(c.headers)-1]); err != nil {
+			return err
+		}
+		c.headers = append(c.headers, header)
+		c.hashes[header.GetHash()] = header.Index
+	}
+	return nil
+}
+
+// Height returns the index of the last header of the chain.
+func (c *HeaderChain) Height() uint32 {
+	return c.Tip().Index
+}
+
+// Tip returns the last header of the chain.
+func (c *HeaderChain) Tip() *Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[len(c.headers)-1]
+}
+
+// GetHeader returns the header at index, nil when it is not in the chain.
+func (c *HeaderChain) GetHeader(index uint32) *Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	first := c.headers[0].Index
+	if index < first || index-first >= uint32(len(c.headers)) {
+		return nil
+	}
+	return c.headers[index-first]
+}
+
+// GetHeaderByHash returns the header with hash, nil when it is not in the chain.
+func (c *HeaderChain) GetHeaderByHash(hash utils.Uint256) *Header {
+	c.mu.RLock()
+	index, ok := c.hashes[hash]
+	c.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return c.GetHeader(index)
+}
+
+// VerifyProof reports whether proof shows that its transaction is in the
+// block at index of the chain.
+func (c *HeaderChain) VerifyProof(index uint32, proof *MerkleProof) bool {
+	header := c.GetHeader(index)
+	return header != nil && proof.VerifyHeader(header)
+}
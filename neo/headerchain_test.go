@@ -0,0 +1,118 @@
+package neo
+
+import (
+	"crypto/ecdsa"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"sort"
+	"testing"
+)
+
+// validators are consensus keys, sorted the way the multisig script lists them.
+type validators []*ecdsa.PrivateKey
+
+func newValidators(t *testing.T, n int) validators {
+	var keys validators
+	for i := 0; i < n; i++ {
+		key, err := NewSigningKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].X.Cmp(keys[j].X) < 0 })
+	return keys
+}
+
+func (v validators) script(t *testing.T) []byte {
+	var pubkeys []*ecdsa.PublicKey
+	for _, key := range v {
+		pubkeys = append(pubkeys, &key.PublicKey)
+	}
+	script, err := CreateMultiSigScript(len(v)-(len(v)-1)/3, pubkeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+// sign signs header with the first m validators.
+func (v validators) sign(t *testing.T, header *Header, m int) {
+	message, _ := header.GetMessage()
+	sb := &ScriptBuilder{}
+	for _, key := range v[:m] {
+		signature, err := Sign(message, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sb.EmitPushBytes(signature)
+	}
+	header.Witness = Witness{InvocationScript: sb.ToBytes(), VerificationScript: v.script(t)}
+}
+
+func TestHeaderChain(t *testing.T) {
+	first, second := newValidators(t, 4), newValidators(t, 4)
+	genesis := &Header{Timestamp: 1468595301, NextConsensus: vm.ScriptHash(first.script(t))}
+	chain := NewHeaderChain(genesis)
+
+	next := func(prev *Header, consensus validators) *Header {
+		return &Header{
+			PrevHash:      prev.GetHash(),
+			Timestamp:     prev.Timestamp + 15,
+			Index:         prev.Index + 1,
+			NextConsensus: vm.ScriptHash(consensus.script(t)),
+		}
+	}
+
+	// The first validators hand over to the second ones.
+	h1 := next(genesis, second)
+	first.sign(t, h1, 3)
+	h2 := next(h1, second)
+	second.sign(t, h2, 3)
+	if err := chain.Add(h1, h2); err != nil {
+		t.Fatal(err)
+	}
+	if chain.Height() != 2 || chain.GetHeaderByHash(h1.GetHash()) != h1 || chain.GetHeader(3) != nil {
+		t.Fatal("chain does not hold the added headers")
+	}
+	// Known headers are skipped.
+	if err := chain.Add(h2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signed by the validators that were replaced.
+	h3 := next(h2, second)
+	first.sign(t, h3, 3)
+	if err := chain.Add(h3); err == nil {
+		t.Fatal("header of former validators accepted")
+	}
+	// Not enough signatures.
+	second.sign(t, h3, 2)
+	if err := chain.Add(h3); err == nil {
+		t.Fatal("header with two of four signatures accepted")
+	}
+	// Not linked to the tip.
+	second.sign(t, h3, 3)
+	h3.PrevHash = h1.GetHash()
+	if err := chain.Add(h3); err == nil {
+		t.Fatal("unlinked header accepted")
+	}
+	h3.PrevHash = h2.GetHash()
+	second.sign(t, h3, 3)
+	if err := chain.Add(h3); err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof checked against a header of the chain.
+	block := &Block{Header: *next(h3, second)}
+	tx, _ := DecodeTransaction([]byte{MinerTransaction, 0, 1, 0, 0, 0, 0, 0, 0, 0})
+	block.Transactions = []*Transaction{tx}
+	block.RebuildMerkleRoot()
+	second.sign(t, &block.Header, 3)
+	if err := chain.Add(block.GetHeader()); err != nil {
+		t.Fatal(err)
+	}
+	proof, _ := block.GetMerkleProof(tx.GetTxId())
+	if !chain.VerifyProof(4, proof) || chain.VerifyProof(3, proof) {
+		t.Fatal("merkle proof checked against the wrong header")
+	}
+}
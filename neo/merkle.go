@@ -0,0 +1,123 @@
+package neo
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// MerkleProof proves that a transaction is part of the block whose Merkle
+// root it hashes to.
+type MerkleProof struct {
+	TxId utils.Uint256
+	// Index is the position of the transaction in the block.
+	Index uint32
+	// Hashes are the siblings on the path from the transaction to the root,
+	// the lowest first.
+	Hashes []utils.Uint256
+}
+
+func merkleParent(left, right utils.Uint256) utils.Uint256 {
+	hash := sha256.Sum256(append(left.Bytes(), right.Bytes()...))
+	return utils.Uint256(sha256.Sum256(hash[:]))
+}
+
+// merkleLevel returns the level above nodes. An odd node out is paired with itself.
+func merkleLevel(nodes []utils.Uint256) []utils.Uint256 {
+	parents := make([]utils.Uint256, (len(nodes)+1)/2)
+	for i := range parents {
+		left := nodes[2*i]
+		right := left
+		if 2*i+1 < len(nodes) {
+			right = nodes[2*i+1]
+		}
+		parents[i] = merkleParent(left, right)
+	}
+	return parents
+}
+
+// ComputeMerkleRoot returns the root of the Merkle tree over hashes, the
+// transaction hashes of a block in order.
+func ComputeMerkleRoot(hashes []utils.Uint256) utils.Uint256 {
+	if len(hashes) == 0 {
+		return utils.Uint256{}
+	}
+	for len(hashes) > 1 {
+		hashes = merkleLevel(hashes)
+	}
+	return hashes[0]
+}
+
+// NewMerkleProof returns the proof that txid is one of hashes.
+func NewMerkleProof(hashes []utils.Uint256, txid utils.Uint256) (*MerkleProof, error) {
+	index := -1
+	for i, hash := range hashes {
+		if hash.Equals(txid) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, errors.New("transaction not in block")
+	}
+
+	proof := &MerkleProof{TxId: txid, Index: uint32(index)}
+	for i := index; len(hashes) > 1; i /= 2 {
+		sibling := i ^ 1
+		if sibling >= len(hashes) {
+			sibling = i
+		}
+		proof.Hashes = append(proof.Hashes, hashes[sibling])
+		hashes = merkleLevel(hashes)
+	}
+	return proof, nil
+}
+
+// Root returns the Merkle root the proof leads to.
+func (p *MerkleProof) Root() utils.Uint256 {
+	hash := p.TxId
+	index := p.Index
+	for _, sibling := range p.Hashes {
+		if index&1 == 0 {
+			hash = merkleParent(hash, sibling)
+		} else {
+			hash = merkleParent(sibling, hash)
+		}
+		index /= 2
+	}
+	return hash
+}
+
+// Verify reports whether the proof leads to root.
+func (p *MerkleProof) Verify(root utils.Uint256) bool {
+	return p.Root() == root
+}
+
+// VerifyHeader reports whether the proof leads to the Merkle root of header.
+func (p *MerkleProof) VerifyHeader(header *Header) bool {
+	return p.Verify(header.MerkleRoot)
+}
+
+// GetTxIds returns the hashes of the transactions of the block.
+func (self *Block) GetTxIds() []utils.Uint256 {
+	hashes := make([]utils.Uint256, len(self.Transactions))
+	for i, tx := range self.Transactions {
+		hashes[i] = tx.GetTxId()
+	}
+	return hashes
+}
+
+// ComputeMerkleRoot returns the Merkle root of the transactions of the block.
+func (self *Block) ComputeMerkleRoot() utils.Uint256 {
+	return ComputeMerkleRoot(self.GetTxIds())
+}
+
+// RebuildMerkleRoot sets MerkleRoot from the transactions of the block.
+func (self *Block) RebuildMerkleRoot() {
+	self.MerkleRoot = self.ComputeMerkleRoot()
+}
+
+// GetMerkleProof returns the proof that the transaction txid is in the block.
+func (self *Block) GetMerkleProof(txid utils.Uint256) (*MerkleProof, error) {
+	return NewMerkleProof(self.GetTxIds(), txid)
+}
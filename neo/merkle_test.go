@@ -0,0 +1,81 @@
+package neo
+
+import (
+	"crypto/sha256"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"testing"
+)
+
+func hash256(data ...[]byte) utils.Uint256 {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	first := h.Sum(nil)
+	return utils.Uint256(sha256.Sum256(first))
+}
+
+func TestMerkle(t *testing.T) {
+	var hashes []utils.Uint256
+	for i := 0; i < 7; i++ {
+		hashes = append(hashes, hash256([]byte{byte(i)}))
+	}
+
+	if root := ComputeMerkleRoot(hashes[:1]); root != hashes[0] {
+		t.Fatal("root of a single transaction is not its hash")
+	}
+	// The third hash is paired with itself.
+	ab := hash256(hashes[0][:], hashes[1][:])
+	cc := hash256(hashes[2][:], hashes[2][:])
+	if root := ComputeMerkleRoot(hashes[:3]); root != hash256(ab[:], cc[:]) {
+		t.Fatalf("root of three transactions %s", root)
+	}
+
+	for n := 1; n <= len(hashes); n++ {
+		root := ComputeMerkleRoot(hashes[:n])
+		for i := 0; i < n; i++ {
+			proof, err := NewMerkleProof(hashes[:n], hashes[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proof.Index != uint32(i) || !proof.Verify(root) {
+				t.Fatalf("proof of %d in %d does not verify", i, n)
+			}
+			// Moving a transaction paired with another breaks the proof.
+			if i^1 < n {
+				proof.Index ^= 1
+				if proof.Verify(root) {
+					t.Fatalf("proof of %d in %d verifies at the wrong index", i, n)
+				}
+			}
+		}
+	}
+
+	proof, _ := NewMerkleProof(hashes, hashes[4])
+	proof.Hashes[1][0] ^= 1
+	if proof.VerifyHeader(&Header{MerkleRoot: ComputeMerkleRoot(hashes)}) {
+		t.Fatal("tampered proof verifies")
+	}
+	if _, err := NewMerkleProof(hashes[:3], hashes[5]); err == nil {
+		t.Fatal("expected error for a transaction not in the block")
+	}
+}
+
+func TestBlockMerkleRoot(t *testing.T) {
+	var block Block
+	for i := 0; i < 3; i++ {
+		tx, err := DecodeTransaction([]byte{MinerTransaction, 0, byte(i), 0, 0, 0, 0, 0, 0, 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	block.RebuildMerkleRoot()
+	if block.MerkleRoot != ComputeMerkleRoot(block.GetTxIds()) {
+		t.Fatal("merkle root not rebuilt")
+	}
+	proof, err := block.GetMerkleProof(block.Transactions[2].GetTxId())
+	if err != nil || !proof.VerifyHeader(block.GetHeader()) {
+		t.Fatalf("block proof %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package neo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+	"strings"
+)
+
+// Nep5Token holds the metadata of a NEP-5 token and, when read in a batch
+// with an account, the balance of that account.
+type Nep5Token struct {
+	Hash        utils.Uint160
+	Name        string
+	Symbol      string
+	Decimals    int
+	TotalSupply *big.Int
+	Balance     *big.Int
+}
+
+// FormatBalance returns the balance with the token decimals applied.
+func (t *Nep5Token) FormatBalance() string {
+	return FormatAmount(t.Balance, t.Decimals)
+}
+
+// FormatTotalSupply returns the total supply with the token decimals applied.
+func (t *Nep5Token) FormatTotalSupply() string {
+	return FormatAmount(t.TotalSupply, t.Decimals)
+}
+
+// Nep5NameScript returns the script calling name on token.
+func Nep5NameScript(token utils.Uint160) []byte {
+	return callScript(token, "name")
+}
+
+// Nep5SymbolScript returns the script calling symbol on token.
+func Nep5SymbolScript(token utils.Uint160) []byte {
+	return callScript(token, "symbol")
+}
+
+// Nep5DecimalsScript returns the script calling decimals on token.
+func Nep5DecimalsScript(token utils.Uint160) []byte {
+	return callScript(token, "decimals")
+}
+
+// Nep5TotalSupplyScript returns the script calling totalSupply on token.
+func Nep5TotalSupplyScript(token utils.Uint160) []byte {
+	return callScript(token, "totalSupply")
+}
+
+// Nep5BalanceOfScript returns the script calling balanceOf(account) on token.
+func Nep5BalanceOfScript(token, account utils.Uint160) []byte {
+	return callScript(token, "balanceOf", account)
+}
+
+// Nep5TransferScript returns the script calling transfer(from, to, amount) on token.
+func Nep5TransferScript(token, from, to utils.Uint160, amount *big.Int) []byte {
+	return callScript(token, "transfer", from, to, amount)
+}
+
+// Nep5ApproveScript returns the script calling the optional
+// approve(owner, spender, amount) on token.
+func Nep5ApproveScript(token, owner, spender utils.Uint160, amount *big.Int) []byte {
+	return callScript(token, "approve", owner, spender, amount)
+}
+
+// Nep5AllowanceScript returns the script calling the optional
+// allowance(owner, spender) on token.
+func Nep5AllowanceScript(token, owner, spender utils.Uint160) []byte {
+	return callScript(token, "allowance", owner, spender)
+}
+
+// Nep5TransferFromScript returns the script calling the optional
+// transferFrom(spender, from, to, amount) on token.
+func Nep5TransferFromScript(token, spender, from, to utils.Uint160, amount *big.Int) []byte {
+	return callScript(token, "transferFrom", spender, from, to, amount)
+}
+
+// Nep5BatchScript returns a script reading name, symbol, decimals and
+// totalSupply of every token, and the balance of account unless it is nil.
+// The script leaves a single array holding one array of results per token,
+// in the order of tokens; decode it with DecodeNep5Batch.
+func Nep5BatchScript(tokens []utils.Uint160, account *utils.Uint160) []byte {
+	sb := &ScriptBuilder{}
+	// PACK puts the top of the stack first, so everything is emitted in reverse.
+	for i := len(tokens) - 1; i >= 0; i-- {
+		count := 4
+		if account != nil {
+			emitCall(sb, tokens[i], "balanceOf", *account)
+			count++
+		}
+		emitCall(sb, tokens[i], "totalSupply")
+		emitCall(sb, tokens[i], "decimals")
+		emitCall(sb, tokens[i], "symbol")
+		emitCall(sb, tokens[i], "name")
+		sb.EmitPushNumber(*big.NewInt(int64(count)))
+		sb.Emit(opcode.PACK, nil)
+	}
+	sb.EmitPushNumber(*big.NewInt(int64(len(tokens))))
+	sb.Emit(opcode.PACK, nil)
+	return sb.ToBytes()
+}
+
+// DecodeNep5Batch decodes the result of the script Nep5BatchScript built for
+// the same tokens. Balances are only set when the script read an account.
+func DecodeNep5Batch(tokens []utils.Uint160, item vm.StackItem) (result []Nep5Token, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("invalid NEP-5 batch result: %v", r)
+		}
+	}()
+
+	list, ok := item.(*vm.Array)
+	if !ok || len(list.Items) != len(tokens) {
+		return nil, errors.New("invalid NEP-5 batch result")
+	}
+	for i, it := range list.Items {
+		fields, ok := it.(*vm.Array)
+		if !ok || len(fields.Items) < 4 {
+			return nil, fmt.Errorf("invalid NEP-5 result for %s", tokens[i])
+		}
+		token := Nep5Token{
+			Hash:        tokens[i],
+			Name:        string(fields.Items[0].GetByteArray()),
+			Symbol:      string(fields.Items[1].GetByteArray()),
+			Decimals:    int(fields.Items[2].GetBigInteger().Int64()),
+			TotalSupply: fields.Items[3].GetBigInteger(),
+		}
+		if len(fields.Items) > 4 {
+			token.Balance = fields.Items[4].GetBigInteger()
+		}
+		result = append(result, token)
+	}
+	return result, nil
+}
+
+// FormatAmount formats an integer token amount with decimals applied,
+// dropping trailing zeros: 150000000 with 8 decimals is "1.5".
+func FormatAmount(amount *big.Int, decimals int) string {
+	if amount == nil {
+		return "0"
+	}
+	s := new(big.Int).Abs(amount).String()
+	if decimals > 0 {
+		if len(s) <= decimals {
+			s = strings.Repeat("0", decimals-len(s)+1) + s
+		}
+		s = strings.TrimRight(s[:len(s)-decimals]+"."+s[len(s)-decimals:], "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if amount.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// ParseAmount parses a decimal token amount into its integer value.
+func ParseAmount(s string, decimals int) (*big.Int, error) {
+	parts := strings.SplitN(s, ".", 2)
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if strings.TrimLeft(parts[0], "+-") == "" && frac == "" {
+		return nil, fmt.Errorf("invalid amount %s", s)
+	}
+	if len(frac) > decimals {
+		return nil, fmt.Errorf("%s has more than %d decimals", s, decimals)
+	}
+	amount, ok := new(big.Int).SetString(parts[0]+frac+strings.Repeat("0", decimals-len(frac)), 10)
+	if !ok || strings.HasPrefix(frac, "-") || strings.HasPrefix(frac, "+") {
+		return nil, fmt.Errorf("invalid amount %s", s)
+	}
+	return amount, nil
+}
@@ -0,0 +1,101 @@
+package neo
+
+import (
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+	"testing"
+)
+
+func TestNep5BatchScript(t *testing.T) {
+	// The token contract hands every call to Test.Token.
+	sb := &ScriptBuilder{}
+	sb.EmitSysCall("Test.Token")
+	contracts := vm.MemoryContracts{}
+	neoToken := contracts.Add(&vm.Contract{Script: sb.ToBytes()})
+	gasToken := contracts.Add(&vm.Contract{Script: append(sb.ToBytes(), 0x61)})
+	account, _ := AddressToScriptHash("ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7")
+
+	e := vm.NewEngine(vm.Application, nil)
+	e.Contracts = contracts
+	e.Register("Test.Token", func(e *vm.Engine) error {
+		operation := string(e.PopBytes())
+		args := e.Pop().(*vm.Array).Items
+		name := "NEO"
+		if e.CurrentContext().ScriptHash.Equals(gasToken) {
+			name = "GAS"
+		}
+		switch operation {
+		case "name", "symbol":
+			e.Push(vm.NewByteArray([]byte(name)))
+		case "decimals":
+			e.Push(vm.NewInteger(big.NewInt(8)))
+		case "totalSupply":
+			e.Push(vm.NewInteger(new(big.Int).SetUint64(100000000 * D)))
+		case "balanceOf":
+			if !vm.NewByteArray(account.BytesReverse()).Equals(args[0]) {
+				t.Fatal("balanceOf account error")
+			}
+			e.Push(vm.NewInteger(big.NewInt(150000000)))
+		}
+		return nil
+	})
+
+	tokens := []utils.Uint160{neoToken, gasToken}
+	result := e.Run(Nep5BatchScript(tokens, &account))
+	if result.State != vm.HALT {
+		t.Fatalf("batch script %s: %v", result.State, result.Err)
+	}
+	infos, err := DecodeNep5Batch(tokens, result.Stack[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infos[0].Symbol != "NEO" || infos[1].Name != "GAS" || infos[1].Decimals != 8 ||
+		infos[1].FormatBalance() != "1.5" || infos[0].FormatTotalSupply() != "100000000" {
+		t.Fatalf("batch result error: %+v", infos)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		decimals int
+		s        string
+	}{
+		{150000000, 8, "1.5"},
+		{1, 8, "0.00000001"},
+		{-2500, 3, "-2.5"},
+		{100, 0, "100"},
+		{0, 8, "0"},
+	}
+	for _, c := range cases {
+		if s := FormatAmount(big.NewInt(c.amount), c.decimals); s != c.s {
+			t.Fatalf("format %d: %s", c.amount, s)
+		}
+		amount, err := ParseAmount(c.s, c.decimals)
+		if err != nil || amount.Int64() != c.amount {
+			t.Fatalf("parse %s: %v %v", c.s, amount, err)
+		}
+	}
+	if _, err := ParseAmount("1.123", 2); err == nil {
+		t.Fatal("expected too many decimals error")
+	}
+	for _, s := range []string{"1.-5", "", ".", "-."} {
+		if _, err := ParseAmount(s, 2); err == nil {
+			t.Fatalf("expected invalid amount error for %q", s)
+		}
+	}
+	if amount, err := ParseAmount(".5", 2); err != nil || amount.Int64() != 50 {
+		t.Fatalf("parse .5: %v %v", amount, err)
+	}
+}
+
+func TestGetNep5Transfer(t *testing.T) {
+	script, ok := GetNep5Transfer("c88acaae8a0362cdbdedddf0083c452a3a8bb7b8", "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7", "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc", *big.NewInt(100000000))
+	if !ok || utils.ToHexString(script) != "0400e1f5051459d2cef5a70f591516cb5888de225d9b4e8edc5e146bc68ea188bf198f4a4dd8aedc0ffd0f28677fad53c1087472616e7366657267b8b78b3a2a453c08f0ddedbdcd62038aaeca8ac8" {
+		t.Fatalf("transfer script %x", script)
+	}
+	if _, ok := GetNep5Transfer("c88acaae8a0362cdbdedddf0083c452a3a8bb7b8", "not an address", "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc", *big.NewInt(1)); ok {
+		t.Fatal("expected invalid address")
+	}
+}
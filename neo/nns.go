@@ -0,0 +1,96 @@
+package neo
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"strings"
+)
+
+// NNSTestNetHash is the script hash of the NNS domain center on the test net.
+var NNSTestNetHash, _ = utils.Uint160DecodeString("348387116c4a75e420663277d9c02049907128c7")
+
+// DefaultNNS is the name service InvokeNNSScript resolves names with.
+var DefaultNNS = &NNS{Hash: NNSTestNetHash}
+
+// NNS builds scripts for the NEO Name Service. Hash is the script hash of
+// the domain center contract, which differs between networks.
+type NNS struct {
+	Hash utils.Uint160
+}
+
+// NameHash returns the NNS hash of domain. The hash of the top level label
+// is its SHA-256, and every label to the left is hashed into its parent:
+// NameHash("test.neo") = sha256(sha256("test") + sha256("neo")).
+func NameHash(domain string) utils.Uint256 {
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(domain, ".")), ".")
+	hash := sha256.Sum256([]byte(labels[len(labels)-1]))
+	root := utils.Uint256(hash)
+	for i := len(labels) - 2; i >= 0; i-- {
+		root = NameHashSub(root, labels[i])
+	}
+	return root
+}
+
+// NameHashSub returns the NNS hash of the label subdomain under root.
+func NameHashSub(root utils.Uint256, subdomain string) utils.Uint256 {
+	if subdomain == "" {
+		return root
+	}
+	hash := sha256.Sum256([]byte(subdomain))
+	return utils.Uint256(sha256.Sum256(append(hash[:], root.Bytes()...)))
+}
+
+// ResolveScript returns the script resolving domain for protocol, such as "addr".
+func (n *NNS) ResolveScript(domain, protocol string) []byte {
+	return callScript(n.Hash, "resolve", protocol, NameHash(domain), "")
+}
+
+// OwnerInfoScript returns the script reading the owner info of domain:
+// its owner, register, resolver, TTL and parent.
+func (n *NNS) OwnerInfoScript(domain string) []byte {
+	return callScript(n.Hash, "getOwnerInfo", NameHash(domain))
+}
+
+// RequestSubDomainScript returns the script asking the registrar of parent
+// to register label for who. The registrar is the register contract in the
+// owner info of parent.
+func RequestSubDomainScript(registrar, who utils.Uint160, parent, label string) []byte {
+	return callScript(registrar, "requestSubDomain", who, NameHash(parent), label)
+}
+
+// SetResolverScript returns the script setting the resolver contract of domain.
+func (n *NNS) SetResolverScript(owner utils.Uint160, domain string, resolver utils.Uint160) []byte {
+	return callScript(n.Hash, "owner_SetResolver", owner, NameHash(domain), resolver)
+}
+
+// SetOwnerScript returns the script transferring domain to a new owner.
+func (n *NNS) SetOwnerScript(owner utils.Uint160, domain string, newOwner utils.Uint160) []byte {
+	return callScript(n.Hash, "owner_SetOwner", owner, NameHash(domain), newOwner)
+}
+
+// SetResolverDataScript returns the script setting the data resolver
+// returns for domain and protocol, such as the address for "addr".
+func SetResolverDataScript(resolver, owner utils.Uint160, domain, protocol string, data []byte) []byte {
+	return callScript(resolver, "setResolverData", owner, NameHash(domain), "", protocol, data)
+}
+
+// DecodeNNSAddress decodes the result of resolving a domain for "addr",
+// which is either an address string or a 20 byte script hash.
+func DecodeNNSAddress(item vm.StackItem) (hash utils.Uint160, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("invalid NNS address")
+		}
+	}()
+
+	data := item.GetByteArray()
+	switch len(data) {
+	case 0:
+		return hash, errors.New("domain is not resolved")
+	case 20:
+		return utils.Uint160DecodeBytes(utils.BytesReverse(data))
+	}
+	return AddressToScriptHash(string(data))
+}
@@ -0,0 +1,63 @@
+package neo
+
+import (
+	"bytes"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"testing"
+)
+
+func TestNameHash(t *testing.T) {
+	hash := NameHash("test.neo")
+	if utils.ToHexString(hash.Bytes()) != "d38e19c187c0eb277533f1657ba284e1967c9b09a8027536d6ee469f21d382b1" {
+		t.Fatalf("name hash %x", hash.Bytes())
+	}
+	if !NameHash("Test.NEO.").Equals(hash) || !NameHashSub(NameHash("neo"), "test").Equals(hash) {
+		t.Fatal("name hash normalization error")
+	}
+	if NameHash("a.test.neo").Equals(hash) {
+		t.Fatal("subdomain hashes to its parent")
+	}
+}
+
+func TestNNSResolve(t *testing.T) {
+	account, _ := AddressToScriptHash("ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7")
+
+	// The domain center resolves test.neo to its address.
+	sb := &ScriptBuilder{}
+	sb.EmitSysCall("Test.NNS")
+	contracts := vm.MemoryContracts{}
+	nns := &NNS{Hash: contracts.Add(&vm.Contract{Script: sb.ToBytes()})}
+
+	resolver := func(e *vm.Engine) error {
+		operation := string(e.PopBytes())
+		args := e.Pop().(*vm.Array).Items
+		hash := NameHash("test.neo")
+		if operation != "resolve" || string(args[0].GetByteArray()) != "addr" || !bytes.Equal(args[1].GetByteArray(), hash.Bytes()) {
+			e.Push(vm.NewByteArray(nil))
+			return nil
+		}
+		e.Push(vm.NewByteArray([]byte(ScriptHashToAddress(account))))
+		return nil
+	}
+	run := func(script []byte) *vm.Result {
+		e := vm.NewEngine(vm.Application, nil)
+		e.Contracts = contracts
+		e.Register("Test.NNS", resolver)
+		return e.Run(script)
+	}
+
+	result := run(nns.ResolveScript("test.neo", "addr"))
+	if result.State != vm.HALT {
+		t.Fatalf("resolve %s: %v", result.State, result.Err)
+	}
+	hash, err := DecodeNNSAddress(result.Stack[0])
+	if err != nil || !hash.Equals(account) {
+		t.Fatalf("resolved %s: %v", hash, err)
+	}
+
+	result = run(nns.ResolveScript("other.neo", "addr"))
+	if _, err := DecodeNNSAddress(result.Stack[0]); err == nil {
+		t.Fatal("expected unresolved domain error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/elliptic"
 	"crypto/sha256"
 	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
 	"golang.org/x/crypto/ripemd160"
 	"math/big"
 )
@@ -171,7 +172,7 @@ func getScriptFromPublicKey(pubkey *ecdsa.PublicKey) []byte {
 	script[0] = 33
 	pubbytes := CompressPublicKey(pubkey)
 	copy(script[1:], pubbytes)
-	script[34] = 172
+	script[34] = opcode.CHECKSIG
 
 	return script
 }
@@ -2,6 +2,7 @@ package neo
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/hzxiao/neo-thinsdk-go/opcode"
 	"github.com/hzxiao/neo-thinsdk-go/simplejson"
 	"github.com/hzxiao/neo-thinsdk-go/utils"
@@ -13,7 +14,7 @@ type ScriptBuilder struct {
 	buf bytes.Buffer
 }
 
-func (sb *ScriptBuilder) toBytes() []byte {
+func (sb *ScriptBuilder) ToBytes() []byte {
 	return sb.buf.Bytes()
 }
 
@@ -58,13 +59,13 @@ func (sb *ScriptBuilder) EmitPushNumber(number big.Int) {
 	}
 
 	var sixteen = big.NewInt(16)
-	if number.Cmp(zero) == 1 && number.Cmp(sixteen) == -1 {
+	if number.Cmp(zero) == 1 && number.Cmp(sixteen) <= 0 {
 		opc := opcode.PUSH1 - 1 + (uint8)(number.Uint64())
 		sb.Emit(opc, []byte{})
 		return
 	}
 
-	sb.EmitPushBytes(number.Bytes())
+	sb.EmitPushBytes(utils.BigIntToBytes(&number))
 }
 
 func (sb *ScriptBuilder) EmitPushBool(b bool) {
@@ -244,3 +245,34 @@ func (sb *ScriptBuilder) pushParam(param interface{}) {
 func (sb *ScriptBuilder) EmitParamJson(param *simplejson.Json) {
 	sb.pushParam(param.Data)
 }
+
+// emitCall emits a call of operation on contract with args packed into an
+// array. Arguments are hashes, strings or byte arrays.
+func emitCall(sb *ScriptBuilder, contract utils.Uint160, operation string, args ...interface{}) {
+	for i := len(args) - 1; i >= 0; i-- {
+		switch arg := args[i].(type) {
+		case utils.Uint160:
+			sb.EmitPushBytes(arg.BytesReverse())
+		case utils.Uint256:
+			sb.EmitPushBytes(arg.Bytes())
+		case string:
+			sb.EmitPushString(arg)
+		case []byte:
+			sb.EmitPushBytes(arg)
+		case *big.Int:
+			sb.EmitPushNumber(*arg)
+		default:
+			panic(fmt.Sprintf("runtime error: unsupported contract argument %T", arg))
+		}
+	}
+	sb.EmitPushNumber(*big.NewInt(int64(len(args))))
+	sb.Emit(opcode.PACK, nil)
+	sb.EmitPushString(operation)
+	sb.EmitAppCall(contract.BytesReverse(), false)
+}
+
+func callScript(contract utils.Uint160, operation string, args ...interface{}) []byte {
+	sb := &ScriptBuilder{}
+	emitCall(sb, contract, operation, args...)
+	return sb.ToBytes()
+}
@@ -0,0 +1,30 @@
+package neo
+
+import (
+	"bytes"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"math/big"
+	"testing"
+)
+
+func TestEmitPushNumber(t *testing.T) {
+	for _, c := range []struct {
+		number int64
+		script []byte
+	}{
+		{-1, []byte{opcode.PUSHM1}},
+		{0, []byte{opcode.PUSH0}},
+		{1, []byte{opcode.PUSH1}},
+		{16, []byte{opcode.PUSH16}},
+		{17, []byte{0x01, 0x11}},
+		{128, []byte{0x02, 0x80, 0x00}},
+		{-2, []byte{0x01, 0xfe}},
+		{100000000, []byte{0x04, 0x00, 0xe1, 0xf5, 0x05}},
+	} {
+		sb := &ScriptBuilder{}
+		sb.EmitPushNumber(*big.NewInt(c.number))
+		if !bytes.Equal(sb.buf.Bytes(), c.script) {
+			t.Fatalf("push %d: %x, expected %x", c.number, sb.buf.Bytes(), c.script)
+		}
+	}
+}
@@ -3,9 +3,14 @@ package neo
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/sha256"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
 	"github.com/hzxiao/neo-thinsdk-go/simplejson"
 	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
 	"math/big"
 )
 
@@ -16,6 +21,7 @@ const (
 	EnrollmentTransaction byte = 0x20
 	RegisterTransaction   byte = 0x40
 	ContractTransaction   byte = 0x80
+	StateTransaction      byte = 0x90
 	PublishTransaction    byte = 0xd0
 	InvocationTransaction byte = 0xd1
 )
@@ -89,6 +95,11 @@ type Attribute struct {
 
 const D uint64 = 100000000
 
+const (
+	NeoAssetId = "c56f33fc6ecfcd0c225c4ab356fee59390af8560be0e930faebe74a6daff7c9b"
+	GasAssetId = "602c79718b16e442de58778e148d0b1084e3b2dffd5de6b7b16cee7969282de7"
+)
+
 type Fixed8 struct {
 	value uint64
 }
@@ -104,6 +115,38 @@ type TransactionInput struct {
 	index uint16
 }
 
+// GetHash returns the hash of the transaction the input spends.
+func (self *TransactionInput) GetHash() utils.Uint256 {
+	var hash utils.Uint256
+	copy(hash[:], self.hash)
+	return hash
+}
+
+// GetIndex returns the index of the output the input spends.
+func (self *TransactionInput) GetIndex() uint16 {
+	return self.index
+}
+
+func (self *TransactionOutput) GetAssetId() utils.Uint256 {
+	var assetId utils.Uint256
+	copy(assetId[:], self.assetId)
+	return assetId
+}
+
+func (self *TransactionOutput) GetValue() uint64 {
+	return self.value.value
+}
+
+func (self *TransactionOutput) GetScriptHash() utils.Uint160 {
+	hash, _ := utils.Uint160DecodeBytes(utils.BytesReverse(self.toAddress))
+	return hash
+}
+
+func (self *TransactionOutput) GetAddress() string {
+	address, _ := GetAddressFromScriptHash(self.toAddress)
+	return address
+}
+
 type Witness struct {
 	InvocationScript   []byte
 	VerificationScript []byte
@@ -131,15 +174,206 @@ func (self *InvokeTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
 }
 
 func (self *InvokeTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
-	length := utils.ReadVarInt(buf, 65535)
-	data := make([]byte, length)
-	buf.Read(data)
-	self.script = data
+	self.script = utils.ReadVarBytes(buf, 65536)
+	if tx.version >= 1 {
+		self.gas.value = binary.LittleEndian.Uint64(utils.ReadBytes(buf, 8))
+	}
+}
+
+type MinerTransData struct {
+	nonce uint32
+}
+
+func (self *MinerTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, self.nonce)
+	buf.Write(data)
+}
+
+func (self *MinerTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
+	self.nonce = binary.LittleEndian.Uint32(utils.ReadBytes(buf, 4))
+}
+
+type ClaimTransData struct {
+	claims []TransactionInput
+}
+
+func (self *ClaimTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
+	utils.WriteVarInt(buf, uint64(len(self.claims)))
+	for _, claim := range self.claims {
+		buf.Write(claim.hash)
+		data := make([]byte, 2)
+		binary.LittleEndian.PutUint16(data, claim.index)
+		buf.Write(data)
+	}
+}
+
+func (self *ClaimTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
+	self.claims = make([]TransactionInput, utils.ReadCount(buf, 65535))
+	for i := range self.claims {
+		self.claims[i].hash = utils.ReadBytes(buf, 32)
+		self.claims[i].index = binary.LittleEndian.Uint16(utils.ReadBytes(buf, 2))
+	}
+}
+
+// RegisterTransData is the asset registered by a RegisterTransaction. Amount
+// is negative for an asset of unlimited supply; Owner is an encoded public
+// key, 0x00 for none.
+type RegisterTransData struct {
+	AssetType byte
+	Name      string
+	Amount    int64
+	Precision byte
+	Owner     []byte
+	Admin     utils.Uint160
+}
+
+const (
+	// GoverningToken is the asset type of NEO, UtilityToken that of GAS.
+	GoverningToken byte = 0x00
+	UtilityToken   byte = 0x01
+)
+
+func (self *RegisterTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
+	buf.WriteByte(self.AssetType)
+	utils.WriteVarBytes(buf, []byte(self.Name))
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, uint64(self.Amount))
+	buf.Write(data)
+	buf.WriteByte(self.Precision)
+	buf.Write(self.Owner)
+	buf.Write(self.Admin.BytesReverse())
+}
+
+func (self *RegisterTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
+	self.AssetType = utils.ReadBytes(buf, 1)[0]
+	self.Name = readVarString(buf, 1024)
+	self.Amount = int64(binary.LittleEndian.Uint64(utils.ReadBytes(buf, 8)))
+	self.Precision = utils.ReadBytes(buf, 1)[0]
+	self.Owner = readPoint(buf)
+	self.Admin, _ = utils.Uint160DecodeBytes(utils.BytesReverse(utils.ReadBytes(buf, 20)))
+}
+
+// EnrollmentTransData is the public key of a validator candidate enrolled
+// by an EnrollmentTransaction.
+type EnrollmentTransData struct {
+	PublicKey []byte
+}
+
+func (self *EnrollmentTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
+	buf.Write(self.PublicKey)
+}
+
+func (self *EnrollmentTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
+	self.PublicKey = readPoint(buf)
+}
+
+// PublishTransData is the contract deployed by a PublishTransaction.
+// NeedStorage is only serialized from version 1 on.
+type PublishTransData struct {
+	Script        []byte
+	ParameterList []ContractParameterType
+	ReturnType    ContractParameterType
+	NeedStorage   bool
+	Name          string
+	CodeVersion   string
+	Author        string
+	Email         string
+	Description   string
+}
+
+func (self *PublishTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
+	utils.WriteVarBytes(buf, self.Script)
+	params := make([]byte, len(self.ParameterList))
+	for i, param := range self.ParameterList {
+		params[i] = byte(param)
+	}
+	utils.WriteVarBytes(buf, params)
+	buf.WriteByte(byte(self.ReturnType))
+	if tx.version >= 1 {
+		if self.NeedStorage {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	}
+	for _, field := range []string{self.Name, self.CodeVersion, self.Author, self.Email, self.Description} {
+		utils.WriteVarBytes(buf, []byte(field))
+	}
+}
+
+func (self *PublishTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
+	self.Script = utils.ReadVarBytes(buf, 1024*1024)
+	params := utils.ReadVarBytes(buf, 65535)
+	self.ParameterList = make([]ContractParameterType, len(params))
+	for i, param := range params {
+		self.ParameterList[i] = ContractParameterType(param)
+	}
+	self.ReturnType = ContractParameterType(utils.ReadBytes(buf, 1)[0])
 	if tx.version >= 1 {
-		value := make([]byte, 8)
-		buf.Read(value)
-		self.gas.value = binary.LittleEndian.Uint64(value)
+		self.NeedStorage = utils.ReadBytes(buf, 1)[0] != 0
+	}
+	self.Name = readVarString(buf, 252)
+	self.CodeVersion = readVarString(buf, 252)
+	self.Author = readVarString(buf, 252)
+	self.Email = readVarString(buf, 252)
+	self.Description = readVarString(buf, 65536)
+}
+
+const (
+	// AccountState descriptors set the votes of an account, ValidatorState
+	// descriptors register a validator.
+	AccountState   byte = 0x40
+	ValidatorState byte = 0x48
+)
+
+// StateDescriptor is a change of the account or validator state.
+type StateDescriptor struct {
+	Type  byte
+	Key   []byte
+	Field string
+	Value []byte
+}
+
+// StateTransData is the state changes of a StateTransaction.
+type StateTransData struct {
+	Descriptors []StateDescriptor
+}
+
+func (self *StateTransData) Serialize(tx *Transaction, buf *bytes.Buffer) {
+	utils.WriteVarInt(buf, uint64(len(self.Descriptors)))
+	for _, d := range self.Descriptors {
+		buf.WriteByte(d.Type)
+		utils.WriteVarBytes(buf, d.Key)
+		utils.WriteVarBytes(buf, []byte(d.Field))
+		utils.WriteVarBytes(buf, d.Value)
+	}
+}
+
+func (self *StateTransData) Deserialize(tx *Transaction, buf *bytes.Buffer) {
+	self.Descriptors = make([]StateDescriptor, utils.ReadCount(buf, 16))
+	for i := range self.Descriptors {
+		d := &self.Descriptors[i]
+		d.Type = utils.ReadBytes(buf, 1)[0]
+		d.Key = utils.ReadVarBytes(buf, 100)
+		d.Field = readVarString(buf, 32)
+		d.Value = utils.ReadVarBytes(buf, 65535)
+	}
+}
+
+// readPoint reads an encoded public key: 0x00 for the point at infinity,
+// otherwise a compressed or uncompressed point.
+func readPoint(buf *bytes.Buffer) []byte {
+	prefix := utils.ReadBytes(buf, 1)[0]
+	switch prefix {
+	case 0x00:
+		return []byte{prefix}
+	case 0x02, 0x03:
+		return append([]byte{prefix}, utils.ReadBytes(buf, 32)...)
+	case 0x04:
+		return append([]byte{prefix}, utils.ReadBytes(buf, 64)...)
 	}
+	panic("runtime error: invalid point encoding")
 }
 
 func (self *Witness) GetAddress() string {
@@ -161,7 +395,7 @@ func (self *Witness) IsSmartContract() bool {
 	if self.VerificationScript[0] != byte(len(self.VerificationScript)-2) {
 		return true
 	}
-	if self.VerificationScript[len(self.VerificationScript)-1] != 0xac {
+	if self.VerificationScript[len(self.VerificationScript)-1] != opcode.CHECKSIG {
 		return true
 	}
 	return false
@@ -196,6 +430,130 @@ func (self *Transaction) GetHash() ([]byte, bool) {
 	return buf.Bytes(), true
 }
 
+// GetTxId returns the transaction hash, the double SHA-256 of the unsigned transaction.
+func (self *Transaction) GetTxId() utils.Uint256 {
+	data, _ := self.GetMessage()
+	hash := sha256.Sum256(data)
+	hash = sha256.Sum256(hash[:])
+	return utils.Uint256(hash)
+}
+
+func (self *Transaction) GetType() byte {
+	return self.txtype
+}
+
+func (self *Transaction) GetVersion() byte {
+	return self.version
+}
+
+func (self *Transaction) GetAttributes() []Attribute {
+	return self.attributes
+}
+
+func (self *Transaction) GetInputs() []TransactionInput {
+	return self.inputs
+}
+
+func (self *Transaction) GetOutputs() []TransactionOutput {
+	return self.outputs
+}
+
+func (self *Transaction) GetWitnesses() []Witness {
+	return self.witnesses
+}
+
+// GetExtData returns the data specific to the transaction type, such as a
+// *RegisterTransData, or nil for types without any.
+func (self *Transaction) GetExtData() IExtData {
+	return self.extdata
+}
+
+// GetScript returns the script of an invocation transaction.
+func (self *Transaction) GetScript() []byte {
+	if data, ok := self.extdata.(*InvokeTransData); ok {
+		return data.script
+	}
+	return nil
+}
+
+// GetGas returns the system fee attached to an invocation transaction.
+func (self *Transaction) GetGas() uint64 {
+	if data, ok := self.extdata.(*InvokeTransData); ok {
+		return data.gas.value
+	}
+	return 0
+}
+
+// GetClaims returns the outputs a claim transaction claims GAS for.
+func (self *Transaction) GetClaims() []TransactionInput {
+	if data, ok := self.extdata.(*ClaimTransData); ok {
+		return data.claims
+	}
+	return nil
+}
+
+// SetGas changes the system fee attached to an unsigned invocation
+// transaction. The difference with the previous fee is taken from, or
+// returned to, the GAS output paid to the change address.
+func (self *Transaction) SetGas(gas uint64, change string) error {
+	data, ok := self.extdata.(*InvokeTransData)
+	if !ok {
+		return errors.New("not an invocation transaction")
+	}
+	if len(self.witnesses) > 0 {
+		return errors.New("transaction is already signed")
+	}
+	changeHash, ok := getPublicKeyHashFromAddress(change)
+	if !ok {
+		return fmt.Errorf("invalid address %s", change)
+	}
+	gasId, _ := utils.ToBytes(GasAssetId)
+	gasId = utils.BytesReverse(gasId)
+
+	index := -1
+	for i, output := range self.outputs {
+		if bytes.Equal(output.assetId, gasId) && bytes.Equal(output.toAddress, changeHash) {
+			index = i
+			break
+		}
+	}
+
+	if gas > data.gas.value {
+		diff := gas - data.gas.value
+		if index < 0 || self.outputs[index].value.value < diff {
+			return errors.New("insufficient GAS for system fee")
+		}
+		self.outputs[index].value.value -= diff
+		if self.outputs[index].value.value == 0 {
+			self.outputs = append(self.outputs[:index], self.outputs[index+1:]...)
+		}
+	} else if gas < data.gas.value {
+		diff := data.gas.value - gas
+		if index < 0 {
+			output := TransactionOutput{assetId: gasId, toAddress: changeHash}
+			output.value.value = diff
+			self.outputs = append(self.outputs, output)
+		} else {
+			self.outputs[index].value.value += diff
+		}
+	}
+	data.gas.value = gas
+	return nil
+}
+
+// DecodeTransaction decodes a serialized transaction.
+func DecodeTransaction(data []byte) (tx *Transaction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tx, err = nil, fmt.Errorf("invalid transaction: %v", r)
+		}
+	}()
+
+	tx = &Transaction{}
+	tx.Deserialize(bytes.NewBuffer(data))
+	return tx, nil
+}
+
 func (self *Transaction) AddWitness(signData []byte, pubkey *ecdsa.PublicKey, addrs string) {
 	buf := &bytes.Buffer{}
 	self.SerializeUnsigned(buf)
@@ -216,7 +574,7 @@ func (self *Transaction) AddWitness(signData []byte, pubkey *ecdsa.PublicKey, ad
 	sb := &ScriptBuilder{}
 	sb.EmitPushBytes(signData)
 
-	iscript := sb.toBytes()
+	iscript := sb.ToBytes()
 	self.AddWitnessScript(vscript, iscript)
 }
 
@@ -238,14 +596,33 @@ func (self *Transaction) AddWitnessScript(script []byte, iscript []byte) bool {
 	return true
 }
 
+// Sign signs the transaction with a WIF private key and adds its witness.
+func (self *Transaction) Sign(wif string) error {
+	privKey := &ecdsa.PrivateKey{}
+	if err := PrivateFromWIF(privKey, wif); err != nil {
+		return err
+	}
+
+	unsignedData, _ := self.GetMessage()
+	signature, err := Sign(unsignedData, privKey)
+	if err != nil {
+		return err
+	}
+
+	pubKey := privKey.PublicKey
+	self.AddWitness(signature, &pubKey, getAddressFromPublicKey(&pubKey))
+	return nil
+}
+
 func (self *Transaction) SerializeUnsigned(buf *bytes.Buffer) {
 	buf.WriteByte(uint8(self.txtype))
 	buf.WriteByte(self.version)
-	if self.txtype == ContractTransaction {
-
-	} else if self.txtype == InvocationTransaction {
+	switch self.txtype {
+	case ContractTransaction, IssueTransaction:
+	case InvocationTransaction, MinerTransaction, ClaimTransaction,
+		RegisterTransaction, EnrollmentTransaction, PublishTransaction, StateTransaction:
 		self.extdata.Serialize(self, buf)
-	} else {
+	default:
 		panic("runtime error: tx type error")
 	}
 
@@ -313,111 +690,90 @@ func (self *Transaction) Serialize(buf *bytes.Buffer) {
 }
 
 func (self *Transaction) Deserialize(buf *bytes.Buffer) {
-	txtype, _ := buf.ReadByte()
-	self.txtype = uint8(txtype)
-	version, _ := buf.ReadByte()
-	self.version = uint8(version)
+	self.deserialize(buf)
+	if buf.Len() > 0 {
+		panic("len error")
+	}
+}
 
-	if txtype == ContractTransaction {
+// deserialize reads one transaction from buf, leaving what follows it, such
+// as the next transaction of a block.
+func (self *Transaction) deserialize(buf *bytes.Buffer) {
+	txtype := utils.ReadBytes(buf, 1)[0]
+	self.txtype = txtype
+	self.version = utils.ReadBytes(buf, 1)[0]
+
+	switch txtype {
+	case ContractTransaction, IssueTransaction:
 		self.extdata = nil
-	} else if txtype == InvocationTransaction {
+	case InvocationTransaction:
 		self.extdata = &InvokeTransData{}
-	} else {
+	case MinerTransaction:
+		self.extdata = &MinerTransData{}
+	case ClaimTransaction:
+		self.extdata = &ClaimTransData{}
+	case RegisterTransaction:
+		self.extdata = &RegisterTransData{}
+	case EnrollmentTransaction:
+		self.extdata = &EnrollmentTransData{}
+	case PublishTransaction:
+		self.extdata = &PublishTransData{}
+	case StateTransaction:
+		self.extdata = &StateTransData{}
+	default:
 		panic("runtime error: tx type error")
 	}
 	if self.extdata != nil {
 		self.extdata.Deserialize(self, buf)
 	}
 
-	countAttri := utils.ReadVarInt(buf, 65535)
+	countAttri := utils.ReadCount(buf, 16)
 	if countAttri > 0 {
 		self.attributes = make([]Attribute, countAttri)
 	}
-	var i uint64 = 0
-	for ; i < countAttri; i++ {
-		usage, _ := buf.ReadByte()
+	for i := 0; i < countAttri; i++ {
+		usage := utils.ReadBytes(buf, 1)[0]
 		self.attributes[i].Usage = usage
 
 		if usage == ContractHash || usage == Vote || (usage >= Hash1 && usage <= Hash15) {
-			attriData := make([]byte, 32)
-			buf.Read(attriData)
-			self.attributes[i].Data = attriData
+			self.attributes[i].Data = utils.ReadBytes(buf, 32)
 		} else if usage == ECDH02 || usage == ECDH03 {
-			attriData := make([]byte, 33)
-			attriData[0] = usage
-			buf.Read(attriData[1:])
-			self.attributes[i].Data = attriData
+			self.attributes[i].Data = append([]byte{usage}, utils.ReadBytes(buf, 32)...)
 		} else if usage == Script {
-			attriData := make([]byte, 20)
-			buf.Read(attriData)
-			self.attributes[i].Data = attriData
+			self.attributes[i].Data = utils.ReadBytes(buf, 20)
 		} else if usage == DescriptionUrl {
-			length, _ := buf.ReadByte()
-			attriData := make([]byte, length)
-			buf.Read(attriData)
-			self.attributes[i].Data = attriData
-
+			self.attributes[i].Data = utils.ReadBytes(buf, int(utils.ReadBytes(buf, 1)[0]))
 		} else if usage == Description || usage >= Remark {
-			length := utils.ReadVarInt(buf, 65535)
-			attriData := make([]byte, length)
-			buf.Read(attriData)
-			self.attributes[i].Data = attriData
+			self.attributes[i].Data = utils.ReadVarBytes(buf, 65535)
 		} else {
 			panic("runtime error: attribute type error")
 		}
 	}
 
-	countInputs := utils.ReadVarInt(buf, 65535)
+	countInputs := utils.ReadCount(buf, 65535)
 	if countInputs > 0 {
 		self.inputs = make([]TransactionInput, countInputs)
 	}
-	i = 0
-	for ; i < countInputs; i++ {
-		hash := make([]byte, 32)
-		buf.Read(hash)
-		self.inputs[i].hash = hash
-
-		indexBytes := make([]byte, 2)
-		buf.Read(indexBytes)
-		self.inputs[i].index = binary.LittleEndian.Uint16(indexBytes)
+	for i := 0; i < countInputs; i++ {
+		self.inputs[i].hash = utils.ReadBytes(buf, 32)
+		self.inputs[i].index = binary.LittleEndian.Uint16(utils.ReadBytes(buf, 2))
 	}
 
-	countOutputs := utils.ReadVarInt(buf, 65535)
+	countOutputs := utils.ReadCount(buf, 65535)
 	if countOutputs > 0 {
 		self.outputs = make([]TransactionOutput, countOutputs)
 	}
-	i = 0
-	for ; i < countOutputs; i++ {
-		assetId := make([]byte, 32)
-		buf.Read(assetId)
-		self.outputs[i].assetId = assetId
-		valueBytes := make([]byte, 8)
-		buf.Read(valueBytes)
-		self.outputs[i].value.value = binary.LittleEndian.Uint64(valueBytes)
-		toAddress := make([]byte, 20)
-		buf.Read(toAddress)
-		self.outputs[i].toAddress = toAddress
-	}
-
-	witnessCount := utils.ReadVarInt(buf, 65535)
-	for i = 0; i < witnessCount; i++ {
-		w := Witness{}
-		icnt := utils.ReadVarInt(buf, 65535)
-		if icnt > 0 {
-			w.InvocationScript = make([]byte, icnt)
-			buf.Read(w.InvocationScript)
-		}
-		vcnt := utils.ReadVarInt(buf, 65535)
-		if vcnt > 0 {
-			w.VerificationScript = make([]byte, vcnt)
-			buf.Read(w.VerificationScript)
-		}
-
-		self.witnesses = append(self.witnesses, w)
+	for i := 0; i < countOutputs; i++ {
+		self.outputs[i].assetId = utils.ReadBytes(buf, 32)
+		self.outputs[i].value.value = binary.LittleEndian.Uint64(utils.ReadBytes(buf, 8))
+		self.outputs[i].toAddress = utils.ReadBytes(buf, 20)
 	}
 
-	if buf.Len() > 0 {
-		panic("len error")
+	witnessCount := utils.ReadCount(buf, 65535)
+	for i := 0; i < witnessCount; i++ {
+		w := Witness{}
+		readWitness(buf, &w)
+		self.witnesses = append(self.witnesses, w)
 	}
 }
 
@@ -440,6 +796,24 @@ type CreateSignParams struct {
 	Data       []byte
 	Utxos      []Utxo
 	DoubleSign bool
+	// Gas is the system fee attached to invocation scripts. It is estimated
+	// from Data when zero.
+	Gas uint64
+	// Claims are the spent NEO outputs a claim transaction claims GAS for,
+	// each with the GAS it can claim as Value, as getclaimable reports them.
+	Claims []Utxo
+}
+
+// invocationGas returns the gas an invocation transaction attaches for params.
+func invocationGas(params *CreateSignParams) (uint64, error) {
+	if params.Gas > 0 {
+		return params.Gas, nil
+	}
+	consumed, err := vm.EstimateGas(params.Data)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(vm.SystemFee(consumed)), nil
 }
 
 func CreateContractTransaction(params *CreateSignParams) (string, string, bool) {
@@ -518,110 +892,59 @@ func InvocationToScript(scriptAddress string, operation string, args []interface
 	sb.EmitPushString(operation)
 	sb.EmitAppCall(assetId, false)
 
-	return sb.toBytes()
+	return sb.ToBytes()
 }
 
 func GetNep5Transfer(scriptAddress string, from, to string, num big.Int) ([]byte, bool) {
-	sb := &ScriptBuilder{}
-	assetId, _ := utils.ToBytes(scriptAddress)
-	assetId = utils.BytesReverse(assetId)
-
-	//jsonData := make(map[string]interface{})
-	var jsonData []interface{}
-
-	fromParam := "(address)" + from
-	//jsonData["from"] = fromParam
-	jsonData = append(jsonData, fromParam)
-
-	toParam := "(address)" + to
-	//jsonData["to"] = toParam
-	jsonData = append(jsonData, toParam)
-
-	strInt := num.String()
-	numParam := "(integer)" + strInt
-	//jsonData["num"] = numParam
-	jsonData = append(jsonData, numParam)
-
-	paramList := &simplejson.Json{Data: jsonData}
-
-	sb.EmitParamJson(paramList)
-	sb.EmitPushString("transfer")
-	sb.EmitAppCall(assetId, false)
-
-	rawdata := sb.toBytes()
-	return rawdata, true
+	token, err := utils.Uint160DecodeString(scriptAddress)
+	if err != nil {
+		return nil, false
+	}
+	fromHash, err := AddressToScriptHash(from)
+	if err != nil {
+		return nil, false
+	}
+	toHash, err := AddressToScriptHash(to)
+	if err != nil {
+		return nil, false
+	}
+	return Nep5TransferScript(token, fromHash, toHash, &num), true
 }
 
+// InvokeNNSScript returns the script resolving the address of domain with DefaultNNS.
 func InvokeNNSScript(domain string) ([]byte, error) {
-
-	var nameHash = []byte("2333333333333333333333333333333323333333333333333333333333333333")
-
-	//jsonData := make(map[string]interface{})
-	var jsonData []interface{}
-
-	fromParam := "(str)" + "addr"
-	//jsonData["from"] = fromParam
-	jsonData = append(jsonData, fromParam)
-
-	toParam := "(hex256)" + utils.ToHexString(nameHash)
-	//jsonData["to"] = toParam
-	jsonData = append(jsonData, toParam)
-
-	numParam := "(str)" + ""
-	//jsonData["num"] = numParam
-	jsonData = append(jsonData, numParam)
-
-	paramList := &simplejson.Json{Data: jsonData}
-
-	sb := &ScriptBuilder{}
-	sb.EmitParamJson(paramList)
-	sb.EmitPushString("resolve")
-	script, err := utils.Uint160DecodeString("348387116c4a75e420663277d9c02049907128c7")
-	if err != nil {
-		return nil, err
+	if domain == "" {
+		return nil, errors.New("empty domain")
 	}
-	sb.EmitAppCall(script.BytesReverse(), false)
-
-	rawdata := sb.toBytes()
-	return rawdata, nil
+	return DefaultNNS.ResolveScript(domain, "addr"), nil
 }
 
+// CreateInvocationTransaction signs an invocation of params.Data paying the
+// inputs to params.To, less the system fee of the script. The fee can only
+// be taken out of GAS inputs.
 func CreateInvocationTransaction(params *CreateSignParams) (string, bool) {
-	tx := &Transaction{}
-	tx.txtype = InvocationTransaction
-	tx.version = params.Version
-
-	var sum uint64 = 0
-	size := len(params.Utxos)
-	if size > 0 {
-		tx.inputs = make([]TransactionInput, size)
+	var sum uint64
+	for _, utxo := range params.Utxos {
+		sum += utxo.Value
 	}
-	for i := 0; i < size; i++ {
-		txid, _ := utils.ToBytes(params.Utxos[i].Hash)
-		tx.inputs[i].hash = utils.BytesReverse(txid)
-		tx.inputs[i].index = params.Utxos[i].N
-		sum += params.Utxos[i].Value
+	if sum <= 0 || len(params.Data) == 0 {
+		return "", false
 	}
-
-	toAddress := params.To
-	if sum <= 0 {
+	gas, err := invocationGas(params)
+	if err != nil || sum < gas {
+		return "", false
+	}
+	if gas > 0 && params.AssetId != GasAssetId {
 		return "", false
 	}
-	assetId := params.AssetId
-	output := TransactionOutput{}
-	vAssetId, _ := utils.ToBytes(assetId)
-	vAssetId = utils.BytesReverse(vAssetId)
-	output.assetId = vAssetId
-	output.value.value = sum
-	pubkeyhash, _ := getPublicKeyHashFromAddress(toAddress)
-	output.toAddress = pubkeyhash
-	tx.outputs = append(tx.outputs, output)
 
-	fromAddress := params.From
-	extdata := &InvokeTransData{}
-	extdata.script = params.Data
-	extdata.gas.value = 100000000
-	tx.extdata = extdata
+	p := *params
+	p.Value = sum - gas
+	p.Gas = gas
+	tx, err := BuildTx(InvocationTransaction, &p)
+	if err != nil {
+		return "", false
+	}
 
 	unsignedData, _ := tx.GetMessage()
 	privKey := &ecdsa.PrivateKey{}
@@ -633,7 +956,7 @@ func CreateInvocationTransaction(params *CreateSignParams) (string, bool) {
 	}
 
 	pubKey := privKey.PublicKey
-	tx.AddWitness(signature, &pubKey, fromAddress)
+	tx.AddWitness(signature, &pubKey, params.From)
 
 	rawData, _ := tx.GetRawData()
 	raw := utils.ToHexString(rawData)
@@ -641,12 +964,23 @@ func CreateInvocationTransaction(params *CreateSignParams) (string, bool) {
 	return raw, true
 }
 
-func CreateTx(txType byte, params *CreateSignParams) (string, string, error) {
+// BuildTx assembles the unsigned transaction described by params. The system
+// fee of an invocation script is paid out of the change, so AssetId must be
+// GAS when the script costs more than the free allowance. A claim
+// transaction pays the GAS of params.Claims to params.To, or params.From.
+func BuildTx(txType byte, params *CreateSignParams) (*Transaction, error) {
 	tx := &Transaction{}
 	tx.txtype = txType
 	tx.version = params.Version
 
 	tx.attributes = params.Attrs
+	if txType == ClaimTransaction {
+		if err := tx.setClaims(params); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+
 	var sum uint64
 	for _, utxo := range params.Utxos {
 		txid, _ := utils.ToBytes(utxo.Hash)
@@ -671,6 +1005,24 @@ func CreateTx(txType byte, params *CreateSignParams) (string, string, error) {
 		sum -= params.Value
 	}
 
+	var gas uint64
+	if len(params.Data) > 0 {
+		var err error
+		gas, err = invocationGas(params)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if gas > 0 {
+		if params.AssetId != GasAssetId {
+			return nil, errors.New("system fee must be paid in GAS")
+		}
+		if sum < gas {
+			return nil, errors.New("insufficient GAS for system fee")
+		}
+		sum -= gas
+	}
+
 	if sum > 0 {
 		assetId, _ := utils.ToBytes(params.AssetId)
 		pubkeyhash, _ := getPublicKeyHashFromAddress(params.From)
@@ -686,10 +1038,19 @@ func CreateTx(txType byte, params *CreateSignParams) (string, string, error) {
 	if len(params.Data) > 0 {
 		extdata := &InvokeTransData{}
 		extdata.script = params.Data
-		extdata.gas.value = 0
+		extdata.gas.value = gas
 		tx.extdata = extdata
 	}
 
+	return tx, nil
+}
+
+func CreateTx(txType byte, params *CreateSignParams) (string, string, error) {
+	tx, err := BuildTx(txType, params)
+	if err != nil {
+		return "", "", err
+	}
+
 	unsignedData, _ := tx.GetMessage()
 	privKey := &ecdsa.PrivateKey{}
 	PrivateFromWIF(privKey, params.PriKey)
@@ -0,0 +1,89 @@
+package neo
+
+import (
+	"bytes"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"reflect"
+	"testing"
+)
+
+func TestCreateInvocationTransaction(t *testing.T) {
+	params := &CreateSignParams{
+		Version: 1,
+		PriKey:  "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1",
+		From:    "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+		To:      "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc",
+		AssetId: GasAssetId,
+		Data:    []byte{0x51},
+		Gas:     2 * D,
+		Utxos:   []Utxo{{Hash: "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 9 * D}},
+	}
+	raw, ok := CreateInvocationTransaction(params)
+	if !ok {
+		t.Fatal("invocation transaction not created")
+	}
+	data, _ := utils.ToBytes(raw)
+	tx, err := DecodeTransaction(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := tx.GetOutputs()
+	if tx.GetGas() != 2*D || len(outputs) != 1 || outputs[0].GetValue() != 7*D ||
+		ScriptHashToAddress(outputs[0].GetScriptHash()) != params.To {
+		t.Fatalf("invocation outputs %+v, gas %d", outputs, tx.GetGas())
+	}
+
+	params.AssetId = NeoAssetId
+	if _, ok := CreateInvocationTransaction(params); ok {
+		t.Fatal("expected the system fee to need GAS")
+	}
+	params.AssetId = GasAssetId
+	params.Gas = 10 * D
+	if _, ok := CreateInvocationTransaction(params); ok {
+		t.Fatal("expected insufficient GAS for the system fee")
+	}
+}
+
+func TestTransactionExtData(t *testing.T) {
+	key, _ := utils.ToBytes("03b209fd4f53a7170ea4444e0cb0a6bb6a53c2bd016926989cf85f9b0fba17a70c")
+	publish := &PublishTransData{
+		Script:        []byte{0x51, 0x66},
+		ParameterList: []ContractParameterType{StringType, ArrayType},
+		ReturnType:    ByteArrayType,
+		NeedStorage:   true,
+		Name:          "name",
+		CodeVersion:   "1.0",
+		Author:        "author",
+		Email:         "email",
+		Description:   "description",
+	}
+	for _, tx := range []*Transaction{
+		{txtype: EnrollmentTransaction, extdata: &EnrollmentTransData{PublicKey: key}},
+		{txtype: PublishTransaction, extdata: publish},
+		{txtype: PublishTransaction, version: 1, extdata: publish},
+		{txtype: StateTransaction, extdata: &StateTransData{Descriptors: []StateDescriptor{
+			{Type: AccountState, Key: make([]byte, 20), Field: "Votes", Value: append([]byte{1}, key...)},
+			{Type: ValidatorState, Key: key, Field: "Registered", Value: []byte{1}},
+		}}},
+	} {
+		buf := &bytes.Buffer{}
+		tx.Serialize(buf)
+		decoded, err := DecodeTransaction(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := tx.extdata
+		if tx.txtype == PublishTransaction && tx.version == 0 {
+			// NeedStorage is not serialized before version 1.
+			data := *publish
+			data.NeedStorage = false
+			expected = &data
+		}
+		if !reflect.DeepEqual(decoded.GetExtData(), expected) || !decoded.GetTxId().Equals(tx.GetTxId()) {
+			t.Fatalf("decoded %x: %+v", buf.Bytes(), decoded.GetExtData())
+		}
+		if _, err := DecodeTransaction(buf.Bytes()[:buf.Len()-1]); err == nil {
+			t.Fatalf("expected error for truncated %x", buf.Bytes())
+		}
+	}
+}
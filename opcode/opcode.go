@@ -111,9 +111,11 @@ const (
 	//因为这个hash函数可能仅仅是csharp 编译时专用的
 	CSHARPSTRHASH32 byte = 0xAB
 	//这个是JAVA专用的
+	// Deprecated: 0xAD is VERIFY in NeoVM 2.x.
 	JAVAHASH32 byte = 0xAD
 
 	CHECKSIG      byte = 0xAC
+	VERIFY        byte = 0xAD // Verifies a signature against a message and public key taken from the stack.
 	CHECKMULTISIG byte = 0xAE
 
 	// Array
@@ -124,9 +126,23 @@ const (
 	SETITEM   byte = 0xC4
 	NEWARRAY  byte = 0xC5 //用作引用類型
 	NEWSTRUCT byte = 0xC6 //用作值類型
+	NEWMAP    byte = 0xC7
+	APPEND    byte = 0xC8
+	REVERSE   byte = 0xC9
+	REMOVE    byte = 0xCA
+	HASKEY    byte = 0xCB
+	KEYS      byte = 0xCC
+	VALUES    byte = 0xCD
 
 	SWITCH byte = 0xD0
 
+	// Stack isolation
+	CALL_I   byte = 0xE0
+	CALL_E   byte = 0xE1
+	CALL_ED  byte = 0xE2
+	CALL_ET  byte = 0xE3
+	CALL_EDT byte = 0xE4
+
 	// Exceptions
 	THROW      byte = 0xF0
 	THROWIFNOT byte = 0xF1
@@ -0,0 +1,212 @@
+package opcode
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// OperandKind describes how the operand of an instruction is laid out in a script.
+type OperandKind byte
+
+const (
+	// OperandNone means the opcode is not followed by any operand.
+	OperandNone OperandKind = iota
+	// OperandFixed means the opcode is followed by exactly Size bytes.
+	OperandFixed
+	// OperandPrefixed means the opcode is followed by a Size byte little-endian
+	// length, and then by that many bytes of data.
+	OperandPrefixed
+)
+
+// Info holds the metadata of a NeoVM 2.x opcode.
+type Info struct {
+	Name    string
+	Operand OperandKind
+	Size    int
+	// Price is the base execution price in units of 0.001 GAS. SYSCALL and
+	// CHECKMULTISIG are priced dynamically; their base price is the price of
+	// the default syscall and of a single public key respectively.
+	Price int64
+}
+
+var table [256]*Info
+
+func define(op byte, name string, operand OperandKind, size int, price int64) {
+	table[op] = &Info{Name: name, Operand: operand, Size: size, Price: price}
+}
+
+func init() {
+	define(PUSH0, "PUSH0", OperandNone, 0, 0)
+	for n := int(PUSHBYTES1); n <= int(PUSHBYTES75); n++ {
+		define(byte(n), fmt.Sprintf("PUSHBYTES%d", n), OperandFixed, n, 0)
+	}
+	define(PUSHDATA1, "PUSHDATA1", OperandPrefixed, 1, 0)
+	define(PUSHDATA2, "PUSHDATA2", OperandPrefixed, 2, 0)
+	define(PUSHDATA4, "PUSHDATA4", OperandPrefixed, 4, 0)
+	define(PUSHM1, "PUSHM1", OperandNone, 0, 0)
+	for n := int(PUSH1); n <= int(PUSH16); n++ {
+		define(byte(n), fmt.Sprintf("PUSH%d", n-int(PUSH1)+1), OperandNone, 0, 0)
+	}
+
+	define(NOP, "NOP", OperandNone, 0, 0)
+	define(JMP, "JMP", OperandFixed, 2, 1)
+	define(JMPIF, "JMPIF", OperandFixed, 2, 1)
+	define(JMPIFNOT, "JMPIFNOT", OperandFixed, 2, 1)
+	define(CALL, "CALL", OperandFixed, 2, 1)
+	define(RET, "RET", OperandNone, 0, 1)
+	define(APPCALL, "APPCALL", OperandFixed, 20, 10)
+	define(SYSCALL, "SYSCALL", OperandPrefixed, 1, 1)
+	define(TAILCALL, "TAILCALL", OperandFixed, 20, 10)
+
+	define(DUPFROMALTSTACK, "DUPFROMALTSTACK", OperandNone, 0, 1)
+	define(TOALTSTACK, "TOALTSTACK", OperandNone, 0, 1)
+	define(FROMALTSTACK, "FROMALTSTACK", OperandNone, 0, 1)
+	define(XDROP, "XDROP", OperandNone, 0, 1)
+	define(XSWAP, "XSWAP", OperandNone, 0, 1)
+	define(XTUCK, "XTUCK", OperandNone, 0, 1)
+	define(DEPTH, "DEPTH", OperandNone, 0, 1)
+	define(DROP, "DROP", OperandNone, 0, 1)
+	define(DUP, "DUP", OperandNone, 0, 1)
+	define(NIP, "NIP", OperandNone, 0, 1)
+	define(OVER, "OVER", OperandNone, 0, 1)
+	define(PICK, "PICK", OperandNone, 0, 1)
+	define(ROLL, "ROLL", OperandNone, 0, 1)
+	define(ROT, "ROT", OperandNone, 0, 1)
+	define(SWAP, "SWAP", OperandNone, 0, 1)
+	define(TUCK, "TUCK", OperandNone, 0, 1)
+
+	define(CAT, "CAT", OperandNone, 0, 1)
+	define(SUBSTR, "SUBSTR", OperandNone, 0, 1)
+	define(LEFT, "LEFT", OperandNone, 0, 1)
+	define(RIGHT, "RIGHT", OperandNone, 0, 1)
+	define(SIZE, "SIZE", OperandNone, 0, 1)
+
+	define(INVERT, "INVERT", OperandNone, 0, 1)
+	define(AND, "AND", OperandNone, 0, 1)
+	define(OR, "OR", OperandNone, 0, 1)
+	define(XOR, "XOR", OperandNone, 0, 1)
+	define(EQUAL, "EQUAL", OperandNone, 0, 1)
+
+	define(INC, "INC", OperandNone, 0, 1)
+	define(DEC, "DEC", OperandNone, 0, 1)
+	define(SIGN, "SIGN", OperandNone, 0, 1)
+	define(NEGATE, "NEGATE", OperandNone, 0, 1)
+	define(ABS, "ABS", OperandNone, 0, 1)
+	define(NOT, "NOT", OperandNone, 0, 1)
+	define(NZ, "NZ", OperandNone, 0, 1)
+	define(ADD, "ADD", OperandNone, 0, 1)
+	define(SUB, "SUB", OperandNone, 0, 1)
+	define(MUL, "MUL", OperandNone, 0, 1)
+	define(DIV, "DIV", OperandNone, 0, 1)
+	define(MOD, "MOD", OperandNone, 0, 1)
+	define(SHL, "SHL", OperandNone, 0, 1)
+	define(SHR, "SHR", OperandNone, 0, 1)
+	define(BOOLAND, "BOOLAND", OperandNone, 0, 1)
+	define(BOOLOR, "BOOLOR", OperandNone, 0, 1)
+	define(NUMEQUAL, "NUMEQUAL", OperandNone, 0, 1)
+	define(NUMNOTEQUAL, "NUMNOTEQUAL", OperandNone, 0, 1)
+	define(LT, "LT", OperandNone, 0, 1)
+	define(GT, "GT", OperandNone, 0, 1)
+	define(LTE, "LTE", OperandNone, 0, 1)
+	define(GTE, "GTE", OperandNone, 0, 1)
+	define(MIN, "MIN", OperandNone, 0, 1)
+	define(MAX, "MAX", OperandNone, 0, 1)
+	define(WITHIN, "WITHIN", OperandNone, 0, 1)
+
+	define(SHA1, "SHA1", OperandNone, 0, 10)
+	define(SHA256, "SHA256", OperandNone, 0, 10)
+	define(HASH160, "HASH160", OperandNone, 0, 20)
+	define(HASH256, "HASH256", OperandNone, 0, 20)
+	define(CHECKSIG, "CHECKSIG", OperandNone, 0, 100)
+	define(VERIFY, "VERIFY", OperandNone, 0, 100)
+	define(CHECKMULTISIG, "CHECKMULTISIG", OperandNone, 0, 100)
+
+	define(ARRAYSIZE, "ARRAYSIZE", OperandNone, 0, 1)
+	define(PACK, "PACK", OperandNone, 0, 1)
+	define(UNPACK, "UNPACK", OperandNone, 0, 1)
+	define(PICKITEM, "PICKITEM", OperandNone, 0, 1)
+	define(SETITEM, "SETITEM", OperandNone, 0, 1)
+	define(NEWARRAY, "NEWARRAY", OperandNone, 0, 1)
+	define(NEWSTRUCT, "NEWSTRUCT", OperandNone, 0, 1)
+	define(NEWMAP, "NEWMAP", OperandNone, 0, 1)
+	define(APPEND, "APPEND", OperandNone, 0, 1)
+	define(REVERSE, "REVERSE", OperandNone, 0, 1)
+	define(REMOVE, "REMOVE", OperandNone, 0, 1)
+	define(HASKEY, "HASKEY", OperandNone, 0, 1)
+	define(KEYS, "KEYS", OperandNone, 0, 1)
+	define(VALUES, "VALUES", OperandNone, 0, 1)
+
+	// The first operand byte holds the return value count and the parameter
+	// count, followed by the jump offset or the called script hash.
+	define(CALL_I, "CALL_I", OperandFixed, 4, 1)
+	define(CALL_E, "CALL_E", OperandFixed, 22, 1)
+	define(CALL_ED, "CALL_ED", OperandFixed, 2, 1)
+	define(CALL_ET, "CALL_ET", OperandFixed, 22, 1)
+	define(CALL_EDT, "CALL_EDT", OperandFixed, 2, 1)
+
+	define(THROW, "THROW", OperandNone, 0, 1)
+	define(THROWIFNOT, "THROWIFNOT", OperandNone, 0, 1)
+}
+
+// Lookup returns the metadata of op. The second result is false if op is not
+// a NeoVM 2.x opcode.
+func Lookup(op byte) (Info, bool) {
+	info := table[op]
+	if info == nil {
+		return Info{}, false
+	}
+	return *info, true
+}
+
+// Name returns the mnemonic of op, or a hex form for unknown opcodes.
+func Name(op byte) string {
+	if info := table[op]; info != nil {
+		return info.Name
+	}
+	return fmt.Sprintf("0x%02X", op)
+}
+
+// Price returns the base execution price of op in units of 0.001 GAS.
+func Price(op byte) int64 {
+	if info := table[op]; info != nil {
+		return info.Price
+	}
+	return 0
+}
+
+// Decode reads the instruction at offset ip of script. It returns the opcode,
+// its operand and the offset of the next instruction.
+func Decode(script []byte, ip int) (byte, []byte, int, error) {
+	if ip < 0 || ip >= len(script) {
+		return 0, nil, ip, fmt.Errorf("offset %d out of script range", ip)
+	}
+	op := script[ip]
+	info := table[op]
+	if info == nil {
+		return op, nil, ip, fmt.Errorf("invalid opcode 0x%02X at offset %d", op, ip)
+	}
+	next := ip + 1
+	size := 0
+	switch info.Operand {
+	case OperandFixed:
+		size = info.Size
+	case OperandPrefixed:
+		if next+info.Size > len(script) {
+			return op, nil, ip, fmt.Errorf("truncated %s at offset %d", info.Name, ip)
+		}
+		prefix := script[next : next+info.Size]
+		switch info.Size {
+		case 1:
+			size = int(prefix[0])
+		case 2:
+			size = int(binary.LittleEndian.Uint16(prefix))
+		case 4:
+			size = int(binary.LittleEndian.Uint32(prefix))
+		}
+		next += info.Size
+	}
+	if size < 0 || next+size > len(script) {
+		return op, nil, ip, fmt.Errorf("truncated %s at offset %d", info.Name, ip)
+	}
+	return op, script[next : next+size], next + size, nil
+}
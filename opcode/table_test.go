@@ -0,0 +1,46 @@
+package opcode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	// PUSHBYTES2 0102, PUSHDATA1 03 aabbcc, SYSCALL "abc", RET
+	script := []byte{0x02, 0x01, 0x02, PUSHDATA1, 0x03, 0xaa, 0xbb, 0xcc, SYSCALL, 0x03, 'a', 'b', 'c', RET}
+
+	expected := []struct {
+		op      byte
+		operand []byte
+	}{
+		{0x02, []byte{0x01, 0x02}},
+		{PUSHDATA1, []byte{0xaa, 0xbb, 0xcc}},
+		{SYSCALL, []byte("abc")},
+		{RET, nil},
+	}
+
+	ip := 0
+	for _, e := range expected {
+		op, operand, next, err := Decode(script, ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op != e.op || !bytes.Equal(operand, e.operand) {
+			t.Fatalf("decode %s at %d: got %x", Name(e.op), ip, operand)
+		}
+		ip = next
+	}
+	if ip != len(script) {
+		t.Fatalf("decode stopped at %d", ip)
+	}
+
+	if _, _, _, err := Decode([]byte{PUSHDATA2, 0x05, 0x00, 0x01}, 0); err == nil {
+		t.Fatal("expected truncated operand error")
+	}
+	if _, ok := Lookup(SWITCH); ok {
+		t.Fatal("SWITCH is not a NeoVM 2.x opcode")
+	}
+	if Name(PUSHBYTES75) != "PUSHBYTES75" || Name(PUSH16) != "PUSH16" || Price(CHECKSIG) != 100 {
+		t.Fatal("opcode table metadata error")
+	}
+}
@@ -0,0 +1,173 @@
+// Package p2p implements the NEO 2.x peer to peer protocol.
+package p2p
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"io"
+)
+
+// Magic numbers of the public networks, the first field of every message.
+const (
+	MainNetMagic uint32 = 7630401
+	TestNetMagic uint32 = 1953787457
+)
+
+// Commands of the messages the codec knows the payload of.
+const (
+	CmdVersion    = "version"
+	CmdVerack     = "verack"
+	CmdGetAddr    = "getaddr"
+	CmdAddr       = "addr"
+	CmdInv        = "inv"
+	CmdGetData    = "getdata"
+	CmdGetHeaders = "getheaders"
+	CmdHeaders    = "headers"
+	CmdGetBlocks  = "getblocks"
+	CmdBlock      = "block"
+	CmdTx         = "tx"
+	CmdPing       = "ping"
+	CmdPong       = "pong"
+)
+
+const (
+	commandSize = 12
+	headerSize  = 4 + commandSize + 4 + 4
+	// MaxPayloadSize is the largest payload a node accepts.
+	MaxPayloadSize = 0x02000000
+)
+
+// Payload is the body of a message.
+type Payload interface {
+	Serialize(buf *bytes.Buffer)
+	Deserialize(buf *bytes.Buffer)
+}
+
+// Message is a protocol message with its payload still serialized.
+type Message struct {
+	Magic   uint32
+	Command string
+	Payload []byte
+}
+
+// NewMessage returns the message carrying payload, which may be nil for
+// commands without one, such as verack and getaddr.
+func NewMessage(magic uint32, command string, payload Payload) *Message {
+	m := &Message{Magic: magic, Command: command}
+	if payload != nil {
+		buf := &bytes.Buffer{}
+		payload.Serialize(buf)
+		m.Payload = buf.Bytes()
+	}
+	return m
+}
+
+func checksum(payload []byte) uint32 {
+	hash := sha256.Sum256(payload)
+	hash = sha256.Sum256(hash[:])
+	return binary.LittleEndian.Uint32(hash[:4])
+}
+
+// Bytes returns the message as sent on the wire.
+func (m *Message) Bytes() []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, headerSize+len(m.Payload)))
+	header := make([]byte, headerSize)
+	binary.LittleEndian.PutUint32(header, m.Magic)
+	copy(header[4:4+commandSize], m.Command)
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(m.Payload)))
+	binary.LittleEndian.PutUint32(header[20:], checksum(m.Payload))
+	buf.Write(header)
+	buf.Write(m.Payload)
+	return buf.Bytes()
+}
+
+// WriteMessage writes m to w.
+func WriteMessage(w io.Writer, m *Message) error {
+	if len(m.Command) > commandSize {
+		return fmt.Errorf("command %q too long", m.Command)
+	}
+	_, err := w.Write(m.Bytes())
+	return err
+}
+
+// ReadMessage reads the next message from r, checking its length and checksum.
+func ReadMessage(r io.Reader) (*Message, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	m := &Message{Magic: binary.LittleEndian.Uint32(header)}
+	command := header[4 : 4+commandSize]
+	if i := bytes.IndexByte(command, 0); i >= 0 {
+		command = command[:i]
+	}
+	m.Command = string(command)
+
+	length := binary.LittleEndian.Uint32(header[16:])
+	if length > MaxPayloadSize {
+		return nil, fmt.Errorf("%s payload of %d bytes too large", m.Command, length)
+	}
+	m.Payload = make([]byte, length)
+	if _, err := io.ReadFull(r, m.Payload); err != nil {
+		return nil, err
+	}
+	if checksum(m.Payload) != binary.LittleEndian.Uint32(header[20:]) {
+		return nil, fmt.Errorf("%s checksum mismatch", m.Command)
+	}
+	return m, nil
+}
+
+// newPayload returns an empty payload for command, nil for commands without one.
+func newPayload(command string) (Payload, error) {
+	switch command {
+	case CmdVersion:
+		return &VersionPayload{}, nil
+	case CmdVerack, CmdGetAddr:
+		return nil, nil
+	case CmdAddr:
+		return &AddrPayload{}, nil
+	case CmdInv, CmdGetData:
+		return &InvPayload{}, nil
+	case CmdGetHeaders, CmdGetBlocks:
+		return &GetBlocksPayload{}, nil
+	case CmdHeaders:
+		return &HeadersPayload{}, nil
+	case CmdBlock:
+		return &neo.Block{}, nil
+	case CmdTx:
+		return &neo.Transaction{}, nil
+	case CmdPing, CmdPong:
+		return &PingPayload{}, nil
+	}
+	return nil, fmt.Errorf("unknown command %q", command)
+}
+
+// DecodePayload decodes the payload of m into the type of its command:
+// *VersionPayload, *AddrPayload, *InvPayload, *GetBlocksPayload,
+// *HeadersPayload, *neo.Block, *neo.Transaction or *PingPayload. It returns
+// nil for verack and getaddr.
+func (m *Message) DecodePayload() (payload Payload, err error) {
+	payload, err = newPayload(m.Command)
+	if err != nil || payload == nil {
+		if err == nil && len(m.Payload) > 0 {
+			err = fmt.Errorf("unexpected %s payload", m.Command)
+		}
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			payload, err = nil, fmt.Errorf("invalid %s payload: %v", m.Command, r)
+		}
+	}()
+	buf := bytes.NewBuffer(m.Payload)
+	payload.Deserialize(buf)
+	if buf.Len() > 0 {
+		return nil, errors.New("len error")
+	}
+	return payload, nil
+}
@@ -0,0 +1,148 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func fixture(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMessage(t *testing.T) {
+	// A main net verack: magic, command, empty payload and its checksum.
+	verack := fixture(t, "416e7400"+"76657261636b000000000000"+"00000000"+"5df6e0e2")
+	if got := NewMessage(MainNetMagic, CmdVerack, nil).Bytes(); !bytes.Equal(got, verack) {
+		t.Fatalf("verack %x", got)
+	}
+	m, err := ReadMessage(bytes.NewReader(verack))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Magic != MainNetMagic || m.Command != CmdVerack || len(m.Payload) != 0 {
+		t.Fatalf("read verack %+v", m)
+	}
+	if payload, err := m.DecodePayload(); err != nil || payload != nil {
+		t.Fatalf("verack payload %v %v", payload, err)
+	}
+
+	corrupted := append([]byte(nil), verack...)
+	corrupted[len(corrupted)-1] ^= 1
+	if _, err := ReadMessage(bytes.NewReader(corrupted)); err == nil {
+		t.Fatal("expected checksum error")
+	}
+	tooLarge := append([]byte(nil), verack...)
+	copy(tooLarge[16:], []byte{0, 0, 0, 0x03})
+	if _, err := ReadMessage(bytes.NewReader(tooLarge)); err == nil {
+		t.Fatal("expected payload size error")
+	}
+	if _, err := (&Message{Command: "mempool"}).DecodePayload(); err == nil {
+		t.Fatal("expected unknown command error")
+	}
+	if _, err := (&Message{Command: CmdPing, Payload: make([]byte, 13)}).DecodePayload(); err == nil {
+		t.Fatal("expected trailing data error")
+	}
+	if _, err := (&Message{Command: CmdInv, Payload: []byte{InvTx}}).DecodePayload(); err == nil {
+		t.Fatal("expected error for an inv without its count")
+	}
+
+	// Messages follow each other on a stream.
+	stream := &bytes.Buffer{}
+	WriteMessage(stream, NewMessage(TestNetMagic, CmdPing, &PingPayload{LastBlockIndex: 1, Nonce: 2}))
+	WriteMessage(stream, NewMessage(TestNetMagic, CmdGetAddr, nil))
+	for _, command := range []string{CmdPing, CmdGetAddr} {
+		if m, err := ReadMessage(stream); err != nil || m.Command != command || m.Magic != TestNetMagic {
+			t.Fatalf("stream message %+v %v", m, err)
+		}
+	}
+}
+
+func TestVersionPayload(t *testing.T) {
+	data := fixture(t, "00000000"+"0100000000000000"+"008e8d5b"+"5d28"+"78563412"+
+		"0b2f4e454f3a322e392e302f"+"80b92a00"+"01")
+	payload, err := (&Message{Command: CmdVersion, Payload: data}).DecodePayload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &VersionPayload{
+		Services:    NodeNetwork,
+		Timestamp:   0x5b8d8e00,
+		Port:        10333,
+		Nonce:       0x12345678,
+		UserAgent:   "/NEO:2.9.0/",
+		StartHeight: 2800000,
+		Relay:       true,
+	}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Fatalf("version %+v", payload)
+	}
+	if m := NewMessage(MainNetMagic, CmdVersion, expected); !bytes.Equal(m.Payload, data) {
+		t.Fatalf("version payload %x", m.Payload)
+	}
+}
+
+func TestAddrPayload(t *testing.T) {
+	data := fixture(t, "01"+"008e8d5b"+"0100000000000000"+"00000000000000000000ffff7f000001"+"285d")
+	payload, err := (&Message{Command: CmdAddr, Payload: data}).DecodePayload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := payload.(*AddrPayload).Addrs
+	if len(addrs) != 1 || addrs[0].TCPAddr().String() != "127.0.0.1:10333" || addrs[0].Services != NodeNetwork {
+		t.Fatalf("addr %+v", addrs)
+	}
+	addr := NetAddr{Timestamp: 0x5b8d8e00, Services: NodeNetwork, IP: net.ParseIP("127.0.0.1"), Port: 10333}
+	if m := NewMessage(MainNetMagic, CmdAddr, &AddrPayload{Addrs: []NetAddr{addr}}); !bytes.Equal(m.Payload, data) {
+		t.Fatalf("addr payload %x", m.Payload)
+	}
+}
+
+func TestPayloads(t *testing.T) {
+	hash, _ := utils.Uint256DecodeString("d42561e3d30e15be6400b6df2f328e02d2bf6354c41dce433bc57687c82144bf")
+	tx, err := neo.DecodeTransaction([]byte{neo.MinerTransaction, 0, 1, 0, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := neo.Header{PrevHash: hash, Index: 1, Witness: neo.Witness{InvocationScript: []byte{0}, VerificationScript: []byte{0x51}}}
+	block := &neo.Block{Header: header, Transactions: []*neo.Transaction{tx}}
+	block.RebuildMerkleRoot()
+
+	payloads := map[string]Payload{
+		CmdInv:        &InvPayload{Type: InvBlock, Hashes: []utils.Uint256{hash}},
+		CmdGetData:    &InvPayload{Type: InvTx, Hashes: []utils.Uint256{hash, {}}},
+		CmdGetHeaders: &GetBlocksPayload{HashStart: []utils.Uint256{hash}},
+		CmdGetBlocks:  &GetBlocksPayload{HashStart: []utils.Uint256{hash}, HashStop: hash},
+		CmdHeaders:    &HeadersPayload{Headers: []*neo.Header{block.GetHeader(), &header}},
+		CmdBlock:      block,
+		CmdTx:         tx,
+		CmdPong:       &PingPayload{LastBlockIndex: 2800000, Timestamp: 1537000000, Nonce: 7},
+	}
+	for command, payload := range payloads {
+		m, err := ReadMessage(bytes.NewReader(NewMessage(MainNetMagic, command, payload).Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := m.DecodePayload()
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		if got := NewMessage(MainNetMagic, command, decoded); !bytes.Equal(got.Payload, m.Payload) {
+			t.Fatalf("%s does not encode back to the same payload", command)
+		}
+	}
+
+	m := NewMessage(MainNetMagic, CmdBlock, block)
+	decoded, _ := m.DecodePayload()
+	if got := decoded.(*neo.Block); !got.GetHash().Equals(block.GetHash()) || got.MerkleRoot != block.MerkleRoot {
+		t.Fatal("block payload decoded to another block")
+	}
+}
@@ -0,0 +1,228 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"net"
+)
+
+// Limits on the number of entries of a payload.
+const (
+	MaxAddrCount    = 200
+	MaxHashCount    = 500
+	MaxHeadersCount = 2000
+)
+
+// Services a node may advertise.
+const (
+	NodeNetwork uint64 = 1
+)
+
+// Inventory types of InvPayload.
+const (
+	InvTx        byte = 0x01
+	InvBlock     byte = 0x02
+	InvConsensus byte = 0xe0
+)
+
+// VersionPayload opens a connection. The node answers with its own version
+// and a verack.
+type VersionPayload struct {
+	Version     uint32
+	Services    uint64
+	Timestamp   uint32
+	Port        uint16
+	Nonce       uint32
+	UserAgent   string
+	StartHeight uint32
+	Relay       bool
+}
+
+func (p *VersionPayload) Serialize(buf *bytes.Buffer) {
+	utils.WriteUint32(buf, p.Version)
+	utils.WriteUint64(buf, p.Services)
+	utils.WriteUint32(buf, p.Timestamp)
+	utils.WriteUint16(buf, p.Port)
+	utils.WriteUint32(buf, p.Nonce)
+	utils.WriteVarBytes(buf, []byte(p.UserAgent))
+	utils.WriteUint32(buf, p.StartHeight)
+	writeBool(buf, p.Relay)
+}
+
+func (p *VersionPayload) Deserialize(buf *bytes.Buffer) {
+	p.Version = readUint32(buf)
+	p.Services = readUint64(buf)
+	p.Timestamp = readUint32(buf)
+	p.Port = readUint16(buf)
+	p.Nonce = readUint32(buf)
+	p.UserAgent = string(utils.ReadVarBytes(buf, 1024))
+	p.StartHeight = readUint32(buf)
+	p.Relay = readBool(buf)
+}
+
+// NetAddr is the address of a node as relayed in addr messages.
+type NetAddr struct {
+	Timestamp uint32
+	Services  uint64
+	IP        net.IP
+	Port      uint16
+}
+
+// TCPAddr returns the address to dial the node at.
+func (a *NetAddr) TCPAddr() *net.TCPAddr {
+	return &net.TCPAddr{IP: a.IP, Port: int(a.Port)}
+}
+
+func (a *NetAddr) Serialize(buf *bytes.Buffer) {
+	utils.WriteUint32(buf, a.Timestamp)
+	utils.WriteUint64(buf, a.Services)
+	ip := a.IP.To16()
+	if ip == nil {
+		ip = make(net.IP, net.IPv6len)
+	}
+	buf.Write(ip)
+	// The port is the only big-endian field of the protocol.
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, a.Port)
+	buf.Write(port)
+}
+
+func (a *NetAddr) Deserialize(buf *bytes.Buffer) {
+	a.Timestamp = readUint32(buf)
+	a.Services = readUint64(buf)
+	a.IP = net.IP(utils.ReadBytes(buf, net.IPv6len))
+	a.Port = binary.BigEndian.Uint16(utils.ReadBytes(buf, 2))
+}
+
+// AddrPayload answers getaddr with the addresses of known nodes.
+type AddrPayload struct {
+	Addrs []NetAddr
+}
+
+func (p *AddrPayload) Serialize(buf *bytes.Buffer) {
+	utils.WriteVarInt(buf, uint64(len(p.Addrs)))
+	for i := range p.Addrs {
+		p.Addrs[i].Serialize(buf)
+	}
+}
+
+func (p *AddrPayload) Deserialize(buf *bytes.Buffer) {
+	p.Addrs = make([]NetAddr, utils.ReadCount(buf, MaxAddrCount))
+	for i := range p.Addrs {
+		p.Addrs[i].Deserialize(buf)
+	}
+}
+
+// InvPayload announces (inv) or requests (getdata) blocks, transactions or
+// consensus messages by hash.
+type InvPayload struct {
+	Type   byte
+	Hashes []utils.Uint256
+}
+
+func (p *InvPayload) Serialize(buf *bytes.Buffer) {
+	buf.WriteByte(p.Type)
+	writeHashes(buf, p.Hashes)
+}
+
+func (p *InvPayload) Deserialize(buf *bytes.Buffer) {
+	p.Type = utils.ReadBytes(buf, 1)[0]
+	p.Hashes = readHashes(buf, MaxHashCount)
+}
+
+// GetBlocksPayload asks for the hashes (getblocks) or headers (getheaders)
+// following HashStart, up to HashStop or the zero hash for as many as allowed.
+type GetBlocksPayload struct {
+	HashStart []utils.Uint256
+	HashStop  utils.Uint256
+}
+
+func (p *GetBlocksPayload) Serialize(buf *bytes.Buffer) {
+	writeHashes(buf, p.HashStart)
+	buf.Write(p.HashStop.Bytes())
+}
+
+func (p *GetBlocksPayload) Deserialize(buf *bytes.Buffer) {
+	p.HashStart = readHashes(buf, 16)
+	copy(p.HashStop[:], utils.ReadBytes(buf, 32))
+}
+
+// HeadersPayload answers getheaders.
+type HeadersPayload struct {
+	Headers []*neo.Header
+}
+
+func (p *HeadersPayload) Serialize(buf *bytes.Buffer) {
+	utils.WriteVarInt(buf, uint64(len(p.Headers)))
+	for _, header := range p.Headers {
+		header.Serialize(buf)
+	}
+}
+
+func (p *HeadersPayload) Deserialize(buf *bytes.Buffer) {
+	p.Headers = make([]*neo.Header, utils.ReadCount(buf, MaxHeadersCount))
+	for i := range p.Headers {
+		p.Headers[i] = &neo.Header{}
+		p.Headers[i].Deserialize(buf)
+	}
+}
+
+// PingPayload carries the height of the sender in ping and pong messages.
+type PingPayload struct {
+	LastBlockIndex uint32
+	Timestamp      uint32
+	Nonce          uint32
+}
+
+func (p *PingPayload) Serialize(buf *bytes.Buffer) {
+	utils.WriteUint32(buf, p.LastBlockIndex)
+	utils.WriteUint32(buf, p.Timestamp)
+	utils.WriteUint32(buf, p.Nonce)
+}
+
+func (p *PingPayload) Deserialize(buf *bytes.Buffer) {
+	p.LastBlockIndex = readUint32(buf)
+	p.Timestamp = readUint32(buf)
+	p.Nonce = readUint32(buf)
+}
+
+func writeBool(buf *bytes.Buffer, v bool) {
+	if v {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+}
+
+func writeHashes(buf *bytes.Buffer, hashes []utils.Uint256) {
+	utils.WriteVarInt(buf, uint64(len(hashes)))
+	for _, hash := range hashes {
+		buf.Write(hash.Bytes())
+	}
+}
+
+func readBool(buf *bytes.Buffer) bool {
+	return utils.ReadBytes(buf, 1)[0] != 0
+}
+
+func readUint16(buf *bytes.Buffer) uint16 {
+	return binary.LittleEndian.Uint16(utils.ReadBytes(buf, 2))
+}
+
+func readUint32(buf *bytes.Buffer) uint32 {
+	return binary.LittleEndian.Uint32(utils.ReadBytes(buf, 4))
+}
+
+func readUint64(buf *bytes.Buffer) uint64 {
+	return binary.LittleEndian.Uint64(utils.ReadBytes(buf, 8))
+}
+
+func readHashes(buf *bytes.Buffer, max uint64) []utils.Uint256 {
+	hashes := make([]utils.Uint256, utils.ReadCount(buf, max))
+	for i := range hashes {
+		copy(hashes[i][:], utils.ReadBytes(buf, 32))
+	}
+	return hashes
+}
@@ -0,0 +1,349 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the version sent in the handshake.
+const ProtocolVersion uint32 = 0
+
+// Config configures the peers of a Relay.
+type Config struct {
+	Magic     uint32
+	UserAgent string
+	// StartHeight is the height announced in the handshake.
+	StartHeight uint32
+	// HandshakeTimeout bounds dialing and the version exchange.
+	HandshakeTimeout time.Duration
+	// OnInv, when set, is called with the inventory a peer announces. It
+	// runs on the goroutine reading from the peer.
+	OnInv func(p *Peer, inv *InvPayload)
+	// OnMessage, when set, is called with the messages the relay does not
+	// handle itself.
+	OnMessage func(p *Peer, m *Message)
+}
+
+// Peer is a connection to a node that completed the handshake.
+type Peer struct {
+	// Version is the version the node sent in the handshake.
+	Version *VersionPayload
+
+	relay *Relay
+	conn  net.Conn
+	send  chan *Message
+	done  chan struct{}
+	once  sync.Once
+	err   error
+}
+
+// Addr returns the address of the node.
+func (p *Peer) Addr() string {
+	return p.conn.RemoteAddr().String()
+}
+
+// Done is closed when the connection ends.
+func (p *Peer) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns why the connection ended, nil while it is open or when it was closed.
+func (p *Peer) Err() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// Send queues m to be written to the node.
+func (p *Peer) Send(m *Message) error {
+	select {
+	case p.send <- m:
+		return nil
+	case <-p.done:
+		return errors.New("peer closed")
+	}
+}
+
+// close ends the connection, recording err as the reason.
+func (p *Peer) close(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+		p.conn.Close()
+	})
+}
+
+func (p *Peer) handshake(deadline time.Time) error {
+	p.conn.SetDeadline(deadline)
+	defer p.conn.SetDeadline(time.Time{})
+
+	r := p.relay
+	version := &VersionPayload{
+		Version:     ProtocolVersion,
+		Services:    NodeNetwork,
+		Timestamp:   uint32(time.Now().Unix()),
+		Nonce:       r.nonce,
+		UserAgent:   r.config.UserAgent,
+		StartHeight: r.config.StartHeight,
+		Relay:       true,
+	}
+	if err := WriteMessage(p.conn, NewMessage(r.config.Magic, CmdVersion, version)); err != nil {
+		return err
+	}
+	for p.Version == nil || !p.gotVerack() {
+		m, err := ReadMessage(p.conn)
+		if err != nil {
+			return err
+		}
+		if m.Magic != r.config.Magic {
+			return fmt.Errorf("unexpected network magic %d", m.Magic)
+		}
+		switch m.Command {
+		case CmdVersion:
+			payload, err := m.DecodePayload()
+			if err != nil {
+				return err
+			}
+			p.Version = payload.(*VersionPayload)
+			if p.Version.Nonce == r.nonce {
+				return errors.New("connected to self")
+			}
+			if err := WriteMessage(p.conn, NewMessage(r.config.Magic, CmdVerack, nil)); err != nil {
+				return err
+			}
+		case CmdVerack:
+			if p.Version == nil {
+				return errors.New("verack before version")
+			}
+			p.send = make(chan *Message, 16)
+		}
+	}
+	return nil
+}
+
+// gotVerack reports whether the handshake saw the verack of the node.
+func (p *Peer) gotVerack() bool {
+	return p.send != nil
+}
+
+func (p *Peer) writeLoop() {
+	for {
+		select {
+		case m := <-p.send:
+			if err := WriteMessage(p.conn, m); err != nil {
+				p.close(err)
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *Peer) readLoop() {
+	for {
+		m, err := ReadMessage(p.conn)
+		if err != nil {
+			p.close(err)
+			return
+		}
+		if err := p.handle(m); err != nil {
+			p.close(err)
+			return
+		}
+	}
+}
+
+func (p *Peer) handle(m *Message) error {
+	r := p.relay
+	switch m.Command {
+	case CmdInv:
+		payload, err := m.DecodePayload()
+		if err != nil {
+			return err
+		}
+		if r.config.OnInv != nil {
+			r.config.OnInv(p, payload.(*InvPayload))
+		}
+	case CmdGetData:
+		payload, err := m.DecodePayload()
+		if err != nil {
+			return err
+		}
+		inv := payload.(*InvPayload)
+		if inv.Type != InvTx {
+			return nil
+		}
+		for _, hash := range inv.Hashes {
+			if raw := r.transaction(hash); raw != nil {
+				p.Send(&Message{Magic: r.config.Magic, Command: CmdTx, Payload: raw})
+			}
+		}
+	case CmdPing:
+		payload, err := m.DecodePayload()
+		if err != nil {
+			return err
+		}
+		ping := payload.(*PingPayload)
+		p.Send(NewMessage(r.config.Magic, CmdPong, &PingPayload{
+			LastBlockIndex: r.config.StartHeight,
+			Timestamp:      uint32(time.Now().Unix()),
+			Nonce:          ping.Nonce,
+		}))
+	default:
+		if r.config.OnMessage != nil {
+			r.config.OnMessage(p, m)
+		}
+	}
+	return nil
+}
+
+// Relay keeps connections to several nodes, announces transactions to all
+// of them and hands the transactions out when a node asks for them.
+type Relay struct {
+	config Config
+	nonce  uint32
+
+	mu    sync.Mutex
+	peers map[*Peer]bool
+	txs   map[utils.Uint256][]byte
+	wg    sync.WaitGroup
+}
+
+// NewRelay returns a relay without peers.
+func NewRelay(config Config) *Relay {
+	if config.HandshakeTimeout == 0 {
+		config.HandshakeTimeout = 10 * time.Second
+	}
+	return &Relay{
+		config: config,
+		nonce:  rand.Uint32(),
+		peers:  make(map[*Peer]bool),
+		txs:    make(map[utils.Uint256][]byte),
+	}
+}
+
+// Connect dials addr and completes the handshake. The peer then runs on its
+// own goroutines until it fails or the relay is closed.
+func (r *Relay) Connect(ctx context.Context, addr string) (*Peer, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.HandshakeTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	p := &Peer{relay: r, conn: conn, done: make(chan struct{})}
+	if err := p.handshake(deadline); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %v", addr, err)
+	}
+
+	r.mu.Lock()
+	r.peers[p] = true
+	r.mu.Unlock()
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		p.readLoop()
+		r.mu.Lock()
+		delete(r.peers, p)
+		r.mu.Unlock()
+	}()
+	go func() {
+		defer r.wg.Done()
+		p.writeLoop()
+	}()
+	return p, nil
+}
+
+// ConnectAll connects to addrs concurrently, returning the peers that
+// completed the handshake and the errors of the others.
+func (r *Relay) ConnectAll(ctx context.Context, addrs ...string) ([]*Peer, []error) {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		peers []*Peer
+		errs  []error
+	)
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			p, err := r.Connect(ctx, addr)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				peers = append(peers, p)
+			}
+		}(addr)
+	}
+	wg.Wait()
+	return peers, errs
+}
+
+// Peers returns the connected peers.
+func (r *Relay) Peers() []*Peer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var peers []*Peer
+	for p := range r.peers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+func (r *Relay) transaction(hash utils.Uint256) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.txs[hash]
+}
+
+// Broadcast keeps tx to serve getdata requests for it and announces it to
+// every peer. It returns the number of peers it was announced to.
+func (r *Relay) Broadcast(tx *neo.Transaction) int {
+	buf := &bytes.Buffer{}
+	tx.Serialize(buf)
+	txid := tx.GetTxId()
+
+	r.mu.Lock()
+	r.txs[txid] = buf.Bytes()
+	r.mu.Unlock()
+
+	inv := NewMessage(r.config.Magic, CmdInv, &InvPayload{Type: InvTx, Hashes: []utils.Uint256{txid}})
+	count := 0
+	for _, p := range r.Peers() {
+		if p.Send(inv) == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Forget stops serving the transaction txid, once it is confirmed for instance.
+func (r *Relay) Forget(txid utils.Uint256) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.txs, txid)
+}
+
+// Close disconnects every peer and waits for their goroutines to end.
+func (r *Relay) Close() {
+	for _, p := range r.Peers() {
+		p.close(nil)
+	}
+	r.wg.Wait()
+}
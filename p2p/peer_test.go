@@ -0,0 +1,137 @@
+package p2p
+
+import (
+	"context"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"net"
+	"testing"
+	"time"
+)
+
+// standInNode accepts one connection, completes the handshake and asks for
+// every transaction announced to it, sending what it receives on txs. It
+// then announces block. The nonces of pongs are sent on pongs.
+func standInNode(t *testing.T, block utils.Uint256, txs chan<- *neo.Transaction, pongs chan<- uint32) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		send := func(command string, payload Payload) {
+			WriteMessage(conn, NewMessage(TestNetMagic, command, payload))
+		}
+		for {
+			m, err := ReadMessage(conn)
+			if err != nil {
+				return
+			}
+			payload, _ := m.DecodePayload()
+			switch m.Command {
+			case CmdVersion:
+				send(CmdVersion, &VersionPayload{Services: NodeNetwork, Nonce: 1, UserAgent: "/stand-in/", StartHeight: 100})
+				send(CmdVerack, nil)
+			case CmdVerack:
+				send(CmdPing, &PingPayload{LastBlockIndex: 100, Nonce: 9})
+			case CmdPong:
+				pongs <- payload.(*PingPayload).Nonce
+			case CmdInv:
+				send(CmdGetData, payload)
+			case CmdTx:
+				txs <- payload.(*neo.Transaction)
+				send(CmdInv, &InvPayload{Type: InvBlock, Hashes: []utils.Uint256{block}})
+			}
+		}
+	}()
+	return l
+}
+
+func TestRelay(t *testing.T) {
+	block, _ := utils.Uint256DecodeString("d42561e3d30e15be6400b6df2f328e02d2bf6354c41dce433bc57687c82144bf")
+	txs := make(chan *neo.Transaction, 2)
+	pongs := make(chan uint32, 2)
+	first := standInNode(t, block, txs, pongs)
+	defer first.Close()
+	second := standInNode(t, block, txs, pongs)
+	defer second.Close()
+
+	announced := make(chan utils.Uint256, 2)
+	relay := NewRelay(Config{
+		Magic:     TestNetMagic,
+		UserAgent: "/neo-thinsdk-go/",
+		OnInv: func(p *Peer, inv *InvPayload) {
+			if inv.Type == InvBlock {
+				announced <- inv.Hashes[0]
+			}
+		},
+	})
+	peers, errs := relay.ConnectAll(context.Background(), first.Addr().String(), second.Addr().String())
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(peers) != 2 || peers[0].Version.UserAgent != "/stand-in/" || peers[0].Version.StartHeight != 100 {
+		t.Fatalf("peers %+v", peers)
+	}
+
+	tx, err := neo.DecodeTransaction([]byte{neo.MinerTransaction, 0, 1, 0, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := relay.Broadcast(tx); n != 2 {
+		t.Fatalf("announced to %d peers", n)
+	}
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-txs:
+			if !got.GetTxId().Equals(tx.GetTxId()) {
+				t.Fatal("node received another transaction")
+			}
+		case <-timeout:
+			t.Fatal("node did not receive the transaction")
+		}
+		select {
+		case hash := <-announced:
+			if !hash.Equals(block) {
+				t.Fatalf("announced %s", hash)
+			}
+		case <-timeout:
+			t.Fatal("block announcement not received")
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case nonce := <-pongs:
+			if nonce != 9 {
+				t.Fatalf("pong nonce %d", nonce)
+			}
+		case <-timeout:
+			t.Fatal("ping not answered")
+		}
+	}
+
+	relay.Close()
+	for _, p := range peers {
+		select {
+		case <-p.Done():
+		default:
+			t.Fatal("peer still running after close")
+		}
+		if p.Err() != nil {
+			t.Fatal(p.Err())
+		}
+	}
+	if len(relay.Peers()) != 0 {
+		t.Fatal("closed peers still listed")
+	}
+
+	first.Close()
+	if _, err := relay.Connect(context.Background(), first.Addr().String()); err == nil {
+		t.Fatal("expected error from a node that is down")
+	}
+}
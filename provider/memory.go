@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"strings"
+	"sync"
+)
+
+// MemoryProvider serves outputs added by hand, for tests.
+type MemoryProvider struct {
+	mu        sync.Mutex
+	unspents  map[string]map[string][]neo.Utxo
+	unclaimed map[string]Unclaimed
+}
+
+// NewMemoryProvider returns an empty MemoryProvider.
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		unspents:  map[string]map[string][]neo.Utxo{},
+		unclaimed: map[string]Unclaimed{},
+	}
+}
+
+// AddUtxo adds unspent outputs of assetId to address.
+func (p *MemoryProvider) AddUtxo(address, assetId string, utxos ...neo.Utxo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assetId = strings.TrimPrefix(assetId, "0x")
+	if p.unspents[address] == nil {
+		p.unspents[address] = map[string][]neo.Utxo{}
+	}
+	p.unspents[address][assetId] = append(p.unspents[address][assetId], utxos...)
+}
+
+// Spend removes the output n of the transaction hash from every address.
+func (p *MemoryProvider) Spend(hash string, n uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, assets := range p.unspents {
+		for assetId, utxos := range assets {
+			kept := utxos[:0]
+			for _, u := range utxos {
+				if u.Hash != hash || u.N != n {
+					kept = append(kept, u)
+				}
+			}
+			assets[assetId] = kept
+		}
+	}
+}
+
+// SetUnclaimed sets the GAS address can claim.
+func (p *MemoryProvider) SetUnclaimed(address string, unclaimed Unclaimed) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unclaimed[address] = unclaimed
+}
+
+func (p *MemoryProvider) GetUnspents(ctx context.Context, address, assetId string) ([]neo.Utxo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	utxos := p.unspents[address][strings.TrimPrefix(assetId, "0x")]
+	return append([]neo.Utxo(nil), utxos...), nil
+}
+
+func (p *MemoryProvider) GetUnclaimed(ctx context.Context, address string) (*Unclaimed, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	unclaimed := p.unclaimed[address]
+	return &unclaimed, nil
+}
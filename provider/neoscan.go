@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+	"net/http"
+	"strings"
+)
+
+// NeoscanMainNet is the neoscan API of the main net.
+const NeoscanMainNet = "https://api.neoscan.io/api/main_net"
+
+// NeoscanProvider looks up outputs with the neoscan REST API.
+type NeoscanProvider struct {
+	// Endpoint is the API root, such as NeoscanMainNet.
+	Endpoint string
+	// HTTPClient sends the requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// NewNeoscanProvider returns a provider using the neoscan API at endpoint.
+func NewNeoscanProvider(endpoint string) *NeoscanProvider {
+	return &NeoscanProvider{Endpoint: endpoint}
+}
+
+func (p *NeoscanProvider) get(ctx context.Context, path string, result interface{}) error {
+	url := strings.TrimSuffix(p.Endpoint, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("neoscan %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (p *NeoscanProvider) GetUnspents(ctx context.Context, address, assetId string) ([]neo.Utxo, error) {
+	// get_balance answers in the format of getunspents.
+	unspents := &rpc.Unspents{}
+	if err := p.get(ctx, "/v1/get_balance/"+address, unspents); err != nil {
+		return nil, err
+	}
+	return utxos(unspents, assetId), nil
+}
+
+func (p *NeoscanProvider) GetUnclaimed(ctx context.Context, address string) (*Unclaimed, error) {
+	var unclaimed, claimable struct {
+		Unclaimed rpc.Fixed8 `json:"unclaimed"`
+	}
+	if err := p.get(ctx, "/v1/get_unclaimed/"+address, &unclaimed); err != nil {
+		return nil, err
+	}
+	if err := p.get(ctx, "/v1/get_claimable/"+address, &claimable); err != nil {
+		return nil, err
+	}
+	result := &Unclaimed{Available: uint64(claimable.Unclaimed)}
+	if unclaimed.Unclaimed > claimable.Unclaimed {
+		result.Unavailable = uint64(unclaimed.Unclaimed - claimable.Unclaimed)
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+// Package provider looks up the unspent outputs and unclaimed GAS of
+// addresses, to fill CreateSignParams.Utxos and claim transactions.
+package provider
+
+import (
+	"context"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+	"strings"
+)
+
+// Unclaimed is the GAS an address can claim, in units of 10^-8 GAS.
+// Available GAS can be claimed now; unavailable GAS was generated by
+// outputs that must be spent first.
+type Unclaimed struct {
+	Available   uint64
+	Unavailable uint64
+}
+
+// Total returns all the GAS generated for the address.
+func (u *Unclaimed) Total() uint64 {
+	return u.Available + u.Unavailable
+}
+
+// UtxoProvider looks up unspent outputs and unclaimed GAS.
+type UtxoProvider interface {
+	// GetUnspents returns the unspent outputs of address holding assetId,
+	// such as neo.NeoAssetId or neo.GasAssetId.
+	GetUnspents(ctx context.Context, address, assetId string) ([]neo.Utxo, error)
+	// GetUnclaimed returns the GAS address can claim.
+	GetUnclaimed(ctx context.Context, address string) (*Unclaimed, error)
+}
+
+// utxos returns the outputs of assetId in unspents as neo.Utxo values.
+func utxos(unspents *rpc.Unspents, assetId string) []neo.Utxo {
+	var result []neo.Utxo
+	for _, balance := range unspents.Balance {
+		if balance.AssetHash.String() != strings.TrimPrefix(assetId, "0x") {
+			continue
+		}
+		for _, u := range balance.Unspent {
+			result = append(result, neo.Utxo{
+				Hash:  u.TxId.String(),
+				Value: uint64(u.Value),
+				N:     u.N,
+			})
+		}
+	}
+	return result
+}
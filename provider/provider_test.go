@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testAddress = "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7"
+
+var balance = map[string]interface{}{
+	"address": testAddress,
+	"balance": []interface{}{
+		map[string]interface{}{
+			"asset_hash": neo.NeoAssetId,
+			"asset":      "NEO",
+			"amount":     15,
+			"unspent": []interface{}{
+				map[string]interface{}{"txid": "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", "n": 0, "value": 10},
+				map[string]interface{}{"txid": "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", "n": 1, "value": 5},
+			},
+		},
+		map[string]interface{}{
+			"asset_hash": "0x" + neo.GasAssetId,
+			"asset":      "GAS",
+			"amount":     1e-8,
+			"unspent": []interface{}{
+				map[string]interface{}{"txid": "0xd233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", "n": 2, "value": 1e-8},
+			},
+		},
+	},
+}
+
+func checkProvider(t *testing.T, p UtxoProvider) {
+	ctx := context.Background()
+	utxos, err := p.GetUnspents(ctx, testAddress, neo.NeoAssetId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxos) != 2 || utxos[0].Hash != "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830" ||
+		utxos[0].Value != 10*neo.D || utxos[1].N != 1 {
+		t.Fatalf("NEO unspents %+v", utxos)
+	}
+	utxos, err = p.GetUnspents(ctx, testAddress, neo.GasAssetId)
+	if err != nil || len(utxos) != 1 || utxos[0].Value != 1 || utxos[0].Hash != "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b" {
+		t.Fatalf("GAS unspents %+v %v", utxos, err)
+	}
+
+	unclaimed, err := p.GetUnclaimed(ctx, testAddress)
+	if err != nil || unclaimed.Available != 150000 || unclaimed.Unavailable != 10000 || unclaimed.Total() != 160000 {
+		t.Fatalf("unclaimed %+v %v", unclaimed, err)
+	}
+}
+
+func TestRpcProvider(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+			Id     uint64        `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Params) == 0 || req.Params[0] != testAddress {
+			t.Errorf("%s params %v", req.Method, req.Params)
+			return
+		}
+		var result interface{}
+		switch req.Method {
+		case "getunspents":
+			result = balance
+		case "getunclaimed":
+			result = map[string]interface{}{"available": 0.0015, "unavailable": 0.0001, "unclaimed": 0.0016}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result})
+	}))
+	defer node.Close()
+
+	checkProvider(t, NewRpcProvider(node.URL))
+}
+
+func TestNeoscanProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/test_net/v1/get_balance/"+testAddress, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(balance)
+	})
+	mux.HandleFunc("/api/test_net/v1/get_unclaimed/"+testAddress, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"unclaimed": 1.6e-3, "address": "` + testAddress + `"}`))
+	})
+	mux.HandleFunc("/api/test_net/v1/get_claimable/"+testAddress, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"unclaimed": 0.0015, "claimable": [], "address": "` + testAddress + `"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checkProvider(t, NewNeoscanProvider(server.URL+"/api/test_net"))
+
+	if _, err := NewNeoscanProvider(server.URL+"/api/main_net").GetUnspents(context.Background(), testAddress, neo.NeoAssetId); err == nil {
+		t.Fatal("expected not found error")
+	}
+}
+
+func TestMemoryProvider(t *testing.T) {
+	p := NewMemoryProvider()
+	p.AddUtxo(testAddress, neo.NeoAssetId,
+		neo.Utxo{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 10 * neo.D, N: 0},
+		neo.Utxo{Hash: "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 5 * neo.D, N: 1})
+	p.AddUtxo(testAddress, "0x"+neo.GasAssetId,
+		neo.Utxo{Hash: "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 1, N: 2},
+		neo.Utxo{Hash: "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 7, N: 3})
+	p.Spend("d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", 3)
+	p.SetUnclaimed(testAddress, Unclaimed{Available: 150000, Unavailable: 10000})
+
+	checkProvider(t, p)
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"context"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+)
+
+// RpcProvider looks up outputs on a node running the RpcSystemAssetTracker plugin.
+type RpcProvider struct {
+	Client *rpc.Client
+}
+
+// NewRpcProvider returns a provider using the node at endpoint.
+func NewRpcProvider(endpoint string) *RpcProvider {
+	return &RpcProvider{Client: rpc.NewClient(endpoint)}
+}
+
+func (p *RpcProvider) GetUnspents(ctx context.Context, address, assetId string) ([]neo.Utxo, error) {
+	unspents, err := p.Client.GetUnspents(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return utxos(unspents, assetId), nil
+}
+
+func (p *RpcProvider) GetUnclaimed(ctx context.Context, address string) (*Unclaimed, error) {
+	unclaimed, err := p.Client.GetUnclaimed(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Unclaimed{
+		Available:   uint64(unclaimed.Available),
+		Unavailable: uint64(unclaimed.Unavailable),
+	}, nil
+}
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// Error is an error returned by a node.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type request struct {
+	JsonRpc string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	Id      uint64        `json:"id"`
+}
+
+type response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+// Transport sends calls to nodes on behalf of a Client.
+type Transport interface {
+	Call(ctx context.Context, method string, params []interface{}, result interface{}) error
+}
+
+// Client is a JSON-RPC client for a NEO 2.x node.
+type Client struct {
+	// id comes first to keep it 64-bit aligned for atomic access on 32-bit
+	// platforms.
+	id uint64
+
+	// Endpoint is the URL of the node, such as http://seed1.neo.org:10332.
+	Endpoint string
+	// HTTPClient sends the requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// Transport, when set, sends the calls instead of Endpoint.
+	Transport Transport
+}
+
+// NewClient returns a client for the node at endpoint.
+func NewClient(endpoint string) *Client {
+	return &Client{Endpoint: endpoint}
+}
+
+// Call calls method with params and decodes its result into result, unless
+// result is nil. Errors returned by the node are of type *Error.
+func (c *Client) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	if c.Transport != nil {
+		return c.Transport.Call(ctx, method, params, result)
+	}
+	return c.post(ctx, method, params, result)
+}
+
+func (c *Client) post(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(&request{
+		JsonRpc: "2.0",
+		Method:  method,
+		Params:  params,
+		Id:      atomic.AddUint64(&c.id, 1),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var res response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: %s", method, resp.Status)
+		}
+		return fmt.Errorf("%s: invalid response: %v", method, err)
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(res.Result, result); err != nil {
+		return fmt.Errorf("%s: invalid result: %v", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testNode serves canned results keyed by method name. A result of type
+// *Error is returned as an error response.
+func testNode(t *testing.T, results map[string]interface{}, check func(method string, params []json.RawMessage)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+			Id     uint64            `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if check != nil {
+			check(req.Method, req.Params)
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id}
+		result, ok := results[req.Method]
+		if !ok {
+			result = &Error{Code: -32601, Message: "Method not found"}
+		}
+		if err, ok := result.(*Error); ok {
+			resp["error"] = err
+		} else {
+			resp["result"] = result
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestClient(t *testing.T) {
+	params := &neo.CreateSignParams{
+		Version: 1,
+		PriKey:  "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1",
+		From:    "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+		To:      "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc",
+		AssetId: neo.NeoAssetId,
+		Value:   1 * neo.D,
+		Utxos:   []neo.Utxo{{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 10 * neo.D}},
+	}
+	_, raw, err := neo.CreateTx(neo.ContractTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	neoAsset, _ := utils.Uint256DecodeString(neo.NeoAssetId)
+	contract, _ := utils.Uint160DecodeString("5b7074e873973a6ed3708862f219a6fbf4d1c411")
+
+	node := testNode(t, map[string]interface{}{
+		"getblockcount":     2800000,
+		"getrawtransaction": raw,
+		"getaccountstate": map[string]interface{}{
+			"version":     0,
+			"script_hash": "0x5b7074e873973a6ed3708862f219a6fbf4d1c411",
+			"frozen":      false,
+			"balances":    []interface{}{map[string]interface{}{"asset": "0x" + neo.NeoAssetId, "value": "100"}},
+		},
+		"getstorage": nil,
+		"invokefunction": map[string]interface{}{
+			"script":       "00c1046e616d6567",
+			"state":        "HALT, BREAK",
+			"gas_consumed": "0.126",
+			"stack":        []interface{}{map[string]interface{}{"type": "ByteArray", "value": "4e454f"}},
+		},
+		"getrawmempool":      []string{"0xb80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830"},
+		"sendrawtransaction": &Error{Code: -501, Message: "Block or transaction validation failed."},
+	}, func(method string, params []json.RawMessage) {
+		if method == "invokefunction" && string(params[2]) != `[{"type":"Hash160","value":"5b7074e873973a6ed3708862f219a6fbf4d1c411"}]` {
+			t.Errorf("invokefunction params %s", params[2])
+		}
+	})
+	defer node.Close()
+
+	ctx := context.Background()
+	c := NewClient(node.URL)
+
+	count, err := c.GetBlockCount(ctx)
+	if err != nil || count != 2800000 {
+		t.Fatalf("getblockcount %d %v", count, err)
+	}
+
+	tx, err := c.GetRawTransaction(ctx, utils.Uint256{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.GetType() != neo.ContractTransaction || len(tx.GetOutputs()) != 2 ||
+		tx.GetOutputs()[0].GetAddress() != params.To || !tx.GetOutputs()[1].GetAssetId().Equals(neoAsset) {
+		t.Fatal("getrawtransaction decode error")
+	}
+
+	state, err := c.GetAccountState(ctx, contract)
+	if err != nil || !state.ScriptHash.Equals(contract) || state.Balance(neoAsset) != Fixed8(100*neo.D) {
+		t.Fatalf("getaccountstate %+v %v", state, err)
+	}
+
+	value, err := c.GetStorage(ctx, contract, []byte("key"))
+	if err != nil || value != nil {
+		t.Fatalf("getstorage %x %v", value, err)
+	}
+
+	result, err := c.InvokeFunction(ctx, contract, "balanceOf", Param{Type: neo.Hash160Type, Value: contract})
+	if err != nil || !result.Halted() || result.GasConsumed != 12600000 || string(result.Stack[0].Value) != `"4e454f"` {
+		t.Fatalf("invokefunction %+v %v", result, err)
+	}
+
+	pool, err := c.GetRawMemPool(ctx)
+	if err != nil || len(pool) != 1 || pool[0].String() != "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830" {
+		t.Fatalf("getrawmempool %v %v", pool, err)
+	}
+
+	if _, err := c.SendTransaction(ctx, tx); err == nil || err.(*Error).Code != -501 {
+		t.Fatalf("sendrawtransaction error %v", err)
+	}
+	if _, err := c.ValidateAddress(ctx, params.To); err == nil {
+		t.Fatal("expected method not found")
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	done := make(chan struct{})
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer node.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := NewClient(node.URL).GetBlockCount(ctx); err == nil {
+		t.Fatal("expected context deadline error")
+	}
+}
+
+func TestNullHashes(t *testing.T) {
+	var tx Transaction
+	data := `{"txid":"0x` + neo.NeoAssetId + `","blockhash":null,"confirmations":0}`
+	if err := json.Unmarshal([]byte(data), &tx); err != nil || tx.TxId.String() != neo.NeoAssetId || tx.BlockHash != (utils.Uint256{}) {
+		t.Fatalf("transaction %+v %v", tx, err)
+	}
+	var script struct {
+		Hash utils.Uint160 `json:"hash"`
+	}
+	if err := json.Unmarshal([]byte(`{"hash":null}`), &script); err != nil || script.Hash != (utils.Uint160{}) {
+		t.Fatalf("script hash %v %v", script.Hash, err)
+	}
+}
@@ -0,0 +1,245 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// unsafeMethods change the state of a node, so they are only retried on
+// another node when the request never left this process.
+var unsafeMethods = map[string]bool{
+	"sendrawtransaction": true,
+	"submitblock":        true,
+}
+
+// NodeStatus is what a Failover knows about one of its nodes.
+type NodeStatus struct {
+	Endpoint string
+	// Height is the block count of the node at the last check.
+	Height uint32
+	// Latency is a moving average of the response time of the node.
+	Latency time.Duration
+	// Failures counts the calls that failed in a row.
+	Failures int
+	// Lagging is set when the node is more than MaxLag blocks behind.
+	Lagging   bool
+	LastError error
+	LastCheck time.Time
+}
+
+type failoverNode struct {
+	// client is replaced, never modified, so calls may use it without the
+	// lock once they have read it under the lock.
+	client *Client
+	status NodeStatus
+}
+
+// Failover is a Transport spreading calls over several nodes. It tracks the
+// block height and latency of every node, routes each call to the healthiest
+// one and retries idempotent calls on the next one when a node fails.
+type Failover struct {
+	// MaxLag is the number of blocks a node may fall behind the highest
+	// node before it is only used as a last resort.
+	MaxLag uint32
+	// OnLagging, when set, is called when a check finds that a node fell
+	// more than MaxLag blocks behind best.
+	OnLagging func(status NodeStatus, best uint32)
+
+	mu    sync.Mutex
+	nodes []*failoverNode
+}
+
+// NewFailover returns a Failover over the nodes at endpoints.
+func NewFailover(endpoints ...string) *Failover {
+	f := &Failover{MaxLag: 5}
+	for _, endpoint := range endpoints {
+		f.nodes = append(f.nodes, &failoverNode{
+			client: NewClient(endpoint),
+			status: NodeStatus{Endpoint: endpoint},
+		})
+	}
+	return f
+}
+
+// NewFailoverClient returns a client calling the nodes at endpoints through a Failover.
+func NewFailoverClient(endpoints ...string) (*Client, *Failover) {
+	f := NewFailover(endpoints...)
+	return &Client{Transport: f}, f
+}
+
+// SetHTTPClient sets the HTTP client every node is called with. Calls in
+// progress finish with the client they started with.
+func (f *Failover) SetHTTPClient(httpClient *http.Client) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, n := range f.nodes {
+		n.client = &Client{Endpoint: n.client.Endpoint, HTTPClient: httpClient}
+	}
+}
+
+// Nodes returns the status of every node, the healthiest first.
+func (f *Failover) Nodes() []NodeStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []NodeStatus
+	for _, n := range f.ranked() {
+		result = append(result, n.status)
+	}
+	return result
+}
+
+// ranked returns the nodes ordered from the healthiest: nodes keeping up
+// with the chain first, then those with the fewest failures in a row, then
+// the fastest. f.mu must be held.
+func (f *Failover) ranked() []*failoverNode {
+	nodes := append([]*failoverNode(nil), f.nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := &nodes[i].status, &nodes[j].status
+		if a.Lagging != b.Lagging {
+			return !a.Lagging
+		}
+		if a.Failures != b.Failures {
+			return a.Failures < b.Failures
+		}
+		return a.Latency < b.Latency
+	})
+	return nodes
+}
+
+// record updates the status of n after a call that took latency.
+func (f *Failover) record(n *failoverNode, latency time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil && !isNodeError(err) {
+		n.status.Failures++
+		n.status.LastError = err
+		return
+	}
+	n.status.Failures = 0
+	if n.status.Latency == 0 {
+		n.status.Latency = latency
+	} else {
+		n.status.Latency = (3*n.status.Latency + latency) / 4
+	}
+}
+
+// isNodeError reports whether err was returned by a node, as opposed to a
+// failure to reach it.
+func isNodeError(err error) bool {
+	var rpcErr *Error
+	return errors.As(err, &rpcErr)
+}
+
+// notSent reports whether a request failing with err never reached the node.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// clientsOf returns the clients of nodes. f.mu must be held.
+func clientsOf(nodes []*failoverNode) []*Client {
+	clients := make([]*Client, len(nodes))
+	for i, n := range nodes {
+		clients[i] = n.client
+	}
+	return clients
+}
+
+// Call sends the call to the healthiest node. Errors returned by a node are
+// final; other failures move idempotent calls on to the next node.
+func (f *Failover) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	f.mu.Lock()
+	nodes := f.ranked()
+	clients := clientsOf(nodes)
+	f.mu.Unlock()
+	if len(nodes) == 0 {
+		return errors.New("failover: no nodes")
+	}
+
+	var err error
+	for i, n := range nodes {
+		start := time.Now()
+		err = clients[i].Call(ctx, method, params, result)
+		f.record(n, time.Since(start), err)
+		if err == nil || isNodeError(err) || ctx.Err() != nil {
+			return err
+		}
+		if unsafeMethods[method] && !notSent(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("failover: all nodes failed: %v", err)
+}
+
+// Check asks every node for its block count, updating heights and latencies,
+// and calls OnLagging for the nodes that newly fell behind.
+func (f *Failover) Check(ctx context.Context) {
+	f.mu.Lock()
+	nodes := append([]*failoverNode(nil), f.nodes...)
+	clients := clientsOf(nodes)
+	f.mu.Unlock()
+
+	var wg sync.WaitGroup
+	heights := make([]uint32, len(nodes))
+	errs := make([]error, len(nodes))
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n *failoverNode) {
+			defer wg.Done()
+			start := time.Now()
+			heights[i], errs[i] = clients[i].GetBlockCount(ctx)
+			f.record(n, time.Since(start), errs[i])
+		}(i, n)
+	}
+	wg.Wait()
+
+	var best uint32
+	for i := range nodes {
+		if errs[i] == nil && heights[i] > best {
+			best = heights[i]
+		}
+	}
+
+	var lagging []NodeStatus
+	f.mu.Lock()
+	for i, n := range nodes {
+		n.status.LastCheck = time.Now()
+		if errs[i] != nil {
+			continue
+		}
+		n.status.Height = heights[i]
+		wasLagging := n.status.Lagging
+		n.status.Lagging = best-heights[i] > f.MaxLag
+		if n.status.Lagging && !wasLagging {
+			lagging = append(lagging, n.status)
+		}
+	}
+	onLagging := f.OnLagging
+	f.mu.Unlock()
+
+	if onLagging != nil {
+		for _, status := range lagging {
+			onLagging(status, best)
+		}
+	}
+}
+
+// Run checks the nodes every interval until ctx is done.
+func (f *Failover) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		f.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// heightNode stands in for a node at height, counting the calls it answers.
+func heightNode(height uint32, delay time.Duration, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+		var req struct {
+			Method string `json:"method"`
+			Id     uint64 `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "sendrawtransaction" {
+			http.Error(w, "stalled", http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": height})
+	}))
+}
+
+func TestFailover(t *testing.T) {
+	var slowCalls, fastCalls, behindCalls int32
+	slow := heightNode(100, 20*time.Millisecond, &slowCalls)
+	defer slow.Close()
+	fast := heightNode(100, 0, &fastCalls)
+	behind := heightNode(80, 0, &behindCalls)
+	defer behind.Close()
+
+	c, f := NewFailoverClient(behind.URL, slow.URL, fast.URL)
+	var lagging []string
+	f.OnLagging = func(status NodeStatus, best uint32) {
+		if best != 100 || status.Height != 80 {
+			t.Fatalf("lagging %+v behind %d", status, best)
+		}
+		lagging = append(lagging, status.Endpoint)
+	}
+	ctx := context.Background()
+	f.Check(ctx)
+	f.Check(ctx)
+	if len(lagging) != 1 || lagging[0] != behind.URL {
+		t.Fatalf("lagging events %v", lagging)
+	}
+	nodes := f.Nodes()
+	if nodes[0].Endpoint != fast.URL || nodes[1].Endpoint != slow.URL || !nodes[2].Lagging {
+		t.Fatalf("node ranking %+v", nodes)
+	}
+
+	atomic.StoreInt32(&fastCalls, 0)
+	if count, err := c.GetBlockCount(ctx); err != nil || count != 100 || atomic.LoadInt32(&fastCalls) != 1 {
+		t.Fatalf("getblockcount %d %v", count, err)
+	}
+
+	// sendrawtransaction reached the node, so it must not be sent again.
+	atomic.StoreInt32(&slowCalls, 0)
+	if _, err := c.SendRawTransaction(ctx, []byte{0x80}); err == nil {
+		t.Fatal("expected sendrawtransaction error")
+	}
+	if atomic.LoadInt32(&slowCalls) != 0 {
+		t.Fatal("sendrawtransaction was retried")
+	}
+
+	// A node that is down is skipped.
+	fast.Close()
+	if count, err := c.GetBlockCount(ctx); err != nil || count != 100 {
+		t.Fatalf("getblockcount after failover %d %v", count, err)
+	}
+	if f.Nodes()[0].Endpoint != slow.URL {
+		t.Fatal("failed node is still preferred")
+	}
+
+	// An unsafe call is moved on only when it could not be delivered at all.
+	c, _ = NewFailoverClient(fast.URL, slow.URL)
+	atomic.StoreInt32(&slowCalls, 0)
+	c.SendRawTransaction(ctx, []byte{0x80})
+	if atomic.LoadInt32(&slowCalls) != 1 {
+		t.Fatal("sendrawtransaction was not sent to the next node")
+	}
+}
+
+func TestFailoverSetHTTPClient(t *testing.T) {
+	var calls int32
+	node := heightNode(100, 0, &calls)
+	defer node.Close()
+	c, f := NewFailoverClient(node.URL)
+
+	// Run with -race: replacing the HTTP client must not race with calls.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			f.SetHTTPClient(&http.Client{Timeout: time.Second})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if _, err := c.GetBlockCount(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Check(context.Background())
+	<-done
+}
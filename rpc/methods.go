@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// GetBlockCount returns the number of blocks in the chain of the node.
+func (c *Client) GetBlockCount(ctx context.Context) (uint32, error) {
+	var count uint32
+	err := c.Call(ctx, "getblockcount", nil, &count)
+	return count, err
+}
+
+// GetBlock returns the block with hash.
+func (c *Client) GetBlock(ctx context.Context, hash utils.Uint256) (*Block, error) {
+	block := &Block{}
+	if err := c.Call(ctx, "getblock", []interface{}{hash.String(), 1}, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockByIndex returns the block at index.
+func (c *Client) GetBlockByIndex(ctx context.Context, index uint32) (*Block, error) {
+	block := &Block{}
+	if err := c.Call(ctx, "getblock", []interface{}{index, 1}, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetRawBlock returns the serialized block at index.
+func (c *Client) GetRawBlock(ctx context.Context, index uint32) ([]byte, error) {
+	var raw HexBytes
+	if err := c.Call(ctx, "getblock", []interface{}{index, 0}, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// GetRawTransaction returns the transaction with txid, confirmed or in the memory pool.
+func (c *Client) GetRawTransaction(ctx context.Context, txid utils.Uint256) (*neo.Transaction, error) {
+	var raw HexBytes
+	if err := c.Call(ctx, "getrawtransaction", []interface{}{txid.String(), 0}, &raw); err != nil {
+		return nil, err
+	}
+	return neo.DecodeTransaction(raw)
+}
+
+// GetTransaction returns the verbose transaction with txid, which includes
+// the block it was confirmed in.
+func (c *Client) GetTransaction(ctx context.Context, txid utils.Uint256) (*Transaction, error) {
+	tx := &Transaction{}
+	if err := c.Call(ctx, "getrawtransaction", []interface{}{txid.String(), 1}, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetAccountState returns the global asset balances of account.
+func (c *Client) GetAccountState(ctx context.Context, account utils.Uint160) (*AccountState, error) {
+	state := &AccountState{}
+	if err := c.Call(ctx, "getaccountstate", []interface{}{neo.ScriptHashToAddress(account)}, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// GetAssetState returns the global asset with assetId.
+func (c *Client) GetAssetState(ctx context.Context, assetId utils.Uint256) (*AssetState, error) {
+	state := &AssetState{}
+	if err := c.Call(ctx, "getassetstate", []interface{}{assetId.String()}, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// GetContractState returns the contract deployed at hash.
+func (c *Client) GetContractState(ctx context.Context, hash utils.Uint160) (*ContractState, error) {
+	state := &ContractState{}
+	if err := c.Call(ctx, "getcontractstate", []interface{}{hash.String()}, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// GetStorage returns the value stored under key by the contract at hash,
+// nil when there is none.
+func (c *Client) GetStorage(ctx context.Context, hash utils.Uint160, key []byte) ([]byte, error) {
+	var value *HexBytes
+	if err := c.Call(ctx, "getstorage", []interface{}{hash.String(), hex.EncodeToString(key)}, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return *value, nil
+}
+
+// InvokeScript runs script on the node without persisting anything.
+func (c *Client) InvokeScript(ctx context.Context, script []byte) (*InvokeResult, error) {
+	result := &InvokeResult{}
+	if err := c.Call(ctx, "invokescript", []interface{}{hex.EncodeToString(script)}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InvokeFunction calls operation on the contract at hash without persisting anything.
+func (c *Client) InvokeFunction(ctx context.Context, hash utils.Uint160, operation string, params ...Param) (*InvokeResult, error) {
+	if params == nil {
+		params = []Param{}
+	}
+	result := &InvokeResult{}
+	if err := c.Call(ctx, "invokefunction", []interface{}{hash.String(), operation, params}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SendRawTransaction broadcasts a serialized transaction. It reports
+// whether the node accepted it into its memory pool.
+func (c *Client) SendRawTransaction(ctx context.Context, raw []byte) (bool, error) {
+	var ok bool
+	err := c.Call(ctx, "sendrawtransaction", []interface{}{hex.EncodeToString(raw)}, &ok)
+	return ok, err
+}
+
+// SendTransaction broadcasts a signed transaction.
+func (c *Client) SendTransaction(ctx context.Context, tx *neo.Transaction) (bool, error) {
+	buf := &bytes.Buffer{}
+	tx.Serialize(buf)
+	return c.SendRawTransaction(ctx, buf.Bytes())
+}
+
+// GetRawMemPool returns the hashes of the transactions in the memory pool of the node.
+func (c *Client) GetRawMemPool(ctx context.Context) ([]utils.Uint256, error) {
+	var hashes []utils.Uint256
+	err := c.Call(ctx, "getrawmempool", nil, &hashes)
+	return hashes, err
+}
+
+// ValidateAddress reports whether the node considers address valid.
+func (c *Client) ValidateAddress(ctx context.Context, address string) (bool, error) {
+	var result struct {
+		Address string `json:"address"`
+		IsValid bool   `json:"isvalid"`
+	}
+	err := c.Call(ctx, "validateaddress", []interface{}{address}, &result)
+	return result.IsValid, err
+}
+
+// GetTxOut returns the output n of the transaction txid, nil when it is spent.
+func (c *Client) GetTxOut(ctx context.Context, txid utils.Uint256, n uint16) (*Output, error) {
+	var output *Output
+	if err := c.Call(ctx, "gettxout", []interface{}{txid.String(), n}, &output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,291 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"math/big"
+	"strings"
+)
+
+type Unspent struct {
+	TxId  utils.Uint256 `json:"txid"`
+	N     uint16        `json:"n"`
+	Value Fixed8        `json:"value"`
+}
+
+type UnspentBalance struct {
+	Unspent     []Unspent     `json:"unspent"`
+	AssetHash   utils.Uint256 `json:"asset_hash"`
+	Asset       string        `json:"asset"`
+	AssetSymbol string        `json:"asset_symbol"`
+	Amount      Fixed8        `json:"amount"`
+}
+
+// Unspents lists the unspent outputs of an address, grouped by asset.
+type Unspents struct {
+	Balance []UnspentBalance `json:"balance"`
+	Address string           `json:"address"`
+}
+
+// Unclaimed is the GAS an address can claim. Available GAS was generated by
+// spent outputs and can be claimed now; unavailable GAS needs the outputs
+// generating it to be spent first.
+type Unclaimed struct {
+	Available   Fixed8 `json:"available"`
+	Unavailable Fixed8 `json:"unavailable"`
+	Unclaimed   Fixed8 `json:"unclaimed"`
+}
+
+// GetUnspents returns the unspent outputs of address, from the
+// RpcSystemAssetTracker plugin.
+func (c *Client) GetUnspents(ctx context.Context, address string) (*Unspents, error) {
+	unspents := &Unspents{}
+	if err := c.Call(ctx, "getunspents", []interface{}{address}, unspents); err != nil {
+		return nil, err
+	}
+	return unspents, nil
+}
+
+// GetUnclaimed returns the unclaimed GAS of address, from the
+// RpcSystemAssetTracker plugin.
+func (c *Client) GetUnclaimed(ctx context.Context, address string) (*Unclaimed, error) {
+	unclaimed := &Unclaimed{}
+	if err := c.Call(ctx, "getunclaimed", []interface{}{address}, unclaimed); err != nil {
+		return nil, err
+	}
+	return unclaimed, nil
+}
+
+// ClaimableOutput is a spent NEO output whose GAS can be claimed.
+type ClaimableOutput struct {
+	TxId        utils.Uint256 `json:"txid"`
+	N           uint16        `json:"n"`
+	Value       Fixed8        `json:"value"`
+	StartHeight uint32        `json:"start_height"`
+	EndHeight   uint32        `json:"end_height"`
+	Generated   Fixed8        `json:"generated"`
+	SysFee      Fixed8        `json:"sys_fee"`
+	Unclaimed   Fixed8        `json:"unclaimed"`
+}
+
+// GasCoin returns the output as a neo.GasCoin, to check the GAS the node
+// reports with neo.GasCoin.Bonus.
+func (o *ClaimableOutput) GasCoin() neo.GasCoin {
+	return neo.GasCoin{
+		Value:       uint64(o.Value),
+		StartHeight: o.StartHeight,
+		EndHeight:   o.EndHeight,
+	}
+}
+
+// Claimable lists the outputs an address can claim GAS for.
+type Claimable struct {
+	Claimable []ClaimableOutput `json:"claimable"`
+	Address   string            `json:"address"`
+	Unclaimed Fixed8            `json:"unclaimed"`
+}
+
+// Claims returns the outputs as the neo.CreateSignParams.Claims of a claim
+// transaction.
+func (c *Claimable) Claims() []neo.Utxo {
+	var claims []neo.Utxo
+	for _, output := range c.Claimable {
+		claims = append(claims, neo.Utxo{
+			Hash:  output.TxId.String(),
+			Value: uint64(output.Unclaimed),
+			N:     output.N,
+		})
+	}
+	return claims
+}
+
+// GetClaimable returns the outputs address can claim GAS for, from the
+// RpcSystemAssetTracker plugin.
+func (c *Client) GetClaimable(ctx context.Context, address string) (*Claimable, error) {
+	claimable := &Claimable{}
+	if err := c.Call(ctx, "getclaimable", []interface{}{address}, claimable); err != nil {
+		return nil, err
+	}
+	return claimable, nil
+}
+
+type Nep5Balance struct {
+	AssetHash        utils.Uint160 `json:"asset_hash"`
+	Amount           BigInt        `json:"amount"`
+	LastUpdatedBlock uint32        `json:"last_updated_block"`
+}
+
+// Nep5Balances lists the NEP-5 balances of an address.
+type Nep5Balances struct {
+	Balance []Nep5Balance `json:"balance"`
+	Address string        `json:"address"`
+}
+
+type Nep5Transfer struct {
+	Timestamp           uint32        `json:"timestamp"`
+	AssetHash           utils.Uint160 `json:"asset_hash"`
+	TransferAddress     string        `json:"transfer_address"`
+	Amount              BigInt        `json:"amount"`
+	BlockIndex          uint32        `json:"block_index"`
+	TransferNotifyIndex uint32        `json:"transfer_notify_index"`
+	TxHash              utils.Uint256 `json:"tx_hash"`
+}
+
+// Nep5Transfers lists the NEP-5 transfers sent and received by an address.
+type Nep5Transfers struct {
+	Sent     []Nep5Transfer `json:"sent"`
+	Received []Nep5Transfer `json:"received"`
+	Address  string         `json:"address"`
+}
+
+// GetNep5Balances returns the NEP-5 balances of address, from the
+// RpcNep5Tracker plugin. Amounts are integers without decimals applied.
+func (c *Client) GetNep5Balances(ctx context.Context, address string) (*Nep5Balances, error) {
+	balances := &Nep5Balances{}
+	if err := c.Call(ctx, "getnep5balances", []interface{}{address}, balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// GetNep5Transfers returns the NEP-5 transfers of address between the unix
+// times start and end, from the RpcNep5Tracker plugin. A zero end uses the
+// defaults of the plugin, which only returns the last seven days.
+func (c *Client) GetNep5Transfers(ctx context.Context, address string, start, end uint32) (*Nep5Transfers, error) {
+	params := []interface{}{address}
+	if end > 0 {
+		params = append(params, start, end)
+	}
+	transfers := &Nep5Transfers{}
+	if err := c.Call(ctx, "getnep5transfers", params, transfers); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+type Notification struct {
+	Contract utils.Uint160 `json:"contract"`
+	State    StackItem     `json:"state"`
+}
+
+// Execution is the result of running one script of a transaction.
+type Execution struct {
+	Trigger       string         `json:"trigger"`
+	Contract      utils.Uint160  `json:"contract"`
+	VMState       string         `json:"vmstate"`
+	GasConsumed   Fixed8         `json:"gas_consumed"`
+	Stack         []StackItem    `json:"stack"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// Halted reports whether the execution ran to completion.
+func (e *Execution) Halted() bool {
+	return strings.Contains(e.VMState, "HALT") && !strings.Contains(e.VMState, "FAULT")
+}
+
+// ApplicationLog is the log the ApplicationLogs plugin keeps of a transaction.
+type ApplicationLog struct {
+	TxId       utils.Uint256 `json:"txid"`
+	Executions []Execution   `json:"executions"`
+}
+
+// Nep5TransferEvent is a transfer notification of a NEP-5 contract.
+// From is empty for minted tokens and To for burnt ones.
+type Nep5TransferEvent struct {
+	TxId     utils.Uint256
+	Contract utils.Uint160
+	From     string
+	To       string
+	Amount   *big.Int
+}
+
+// GetApplicationLog returns the application log of the transaction txid,
+// from the ApplicationLogs plugin.
+func (c *Client) GetApplicationLog(ctx context.Context, txid utils.Uint256) (*ApplicationLog, error) {
+	var raw json.RawMessage
+	if err := c.Call(ctx, "getapplicationlog", []interface{}{txid.String()}, &raw); err != nil {
+		return nil, err
+	}
+	log := &ApplicationLog{}
+	if err := json.Unmarshal(raw, log); err != nil {
+		return nil, err
+	}
+	if len(log.Executions) == 0 {
+		// Plugins before NEO 2.9 log a single execution at the top level.
+		execution := Execution{}
+		if err := json.Unmarshal(raw, &execution); err != nil {
+			return nil, err
+		}
+		if execution.VMState != "" {
+			log.Executions = append(log.Executions, execution)
+		}
+	}
+	return log, nil
+}
+
+// Nep5Transfers returns the NEP-5 transfers notified by the executions that
+// halted. Notifications which are not transfers are skipped.
+func (l *ApplicationLog) Nep5Transfers() []Nep5TransferEvent {
+	var events []Nep5TransferEvent
+	for _, e := range l.Executions {
+		if !e.Halted() {
+			continue
+		}
+		for _, n := range e.Notifications {
+			if event, ok := nep5TransferEvent(n); ok {
+				event.TxId = l.TxId
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// GetBlockNep5Transfers returns the NEP-5 transfers of the invocation
+// transactions of block, from their application logs.
+func (c *Client) GetBlockNep5Transfers(ctx context.Context, block *neo.Block) ([]Nep5TransferEvent, error) {
+	var transfers []Nep5TransferEvent
+	for _, tx := range block.Transactions {
+		if tx.GetType() != neo.InvocationTransaction {
+			continue
+		}
+		log, err := c.GetApplicationLog(ctx, tx.GetTxId())
+		if err != nil {
+			return nil, fmt.Errorf("application log of %s: %v", tx.GetTxId(), err)
+		}
+		transfers = append(transfers, log.Nep5Transfers()...)
+	}
+	return transfers, nil
+}
+
+// nep5TransferEvent decodes a ["transfer", from, to, amount] notification.
+func nep5TransferEvent(n Notification) (Nep5TransferEvent, bool) {
+	event := Nep5TransferEvent{Contract: n.Contract}
+	state, err := n.State.GetArray()
+	if err != nil || len(state) != 4 {
+		return event, false
+	}
+	name, err := state[0].GetByteArray()
+	if err != nil || string(name) != "transfer" {
+		return event, false
+	}
+	addresses := make([]string, 2)
+	for i, item := range state[1:3] {
+		hash, err := item.GetByteArray()
+		if err != nil || (len(hash) != 0 && len(hash) != 20) {
+			return event, false
+		}
+		if len(hash) == 20 {
+			addresses[i], _ = neo.GetAddressFromScriptHash(hash)
+		}
+	}
+	event.From, event.To = addresses[0], addresses[1]
+	event.Amount, err = state[3].GetBigInteger()
+	if err != nil {
+		return event, false
+	}
+	return event, true
+}
@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"testing"
+)
+
+const testApplicationLog = `{
+	"txid": "0xb80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830",
+	"executions": [{
+		"trigger": "Application",
+		"contract": "0x2a0aac8bf1d8e4e74e2ba48d84b2fe5c3d2e22c6",
+		"vmstate": "HALT",
+		"gas_consumed": "2.855",
+		"stack": [{"type": "Integer", "value": "1"}],
+		"notifications": [
+			{"contract": "0xc88acaae8a0362cdbdedddf0083c452a3a8bb7b8", "state": {"type": "Array", "value": [
+				{"type": "ByteArray", "value": "7472616e73666572"},
+				{"type": "ByteArray", "value": "6bc68ea188bf198f4a4dd8aedc0ffd0f28677fad"},
+				{"type": "ByteArray", "value": "59d2cef5a70f591516cb5888de225d9b4e8edc5e"},
+				{"type": "ByteArray", "value": "00e1f505"}
+			]}},
+			{"contract": "0xc88acaae8a0362cdbdedddf0083c452a3a8bb7b8", "state": {"type": "Array", "value": [
+				{"type": "ByteArray", "value": "7472616e73666572"},
+				{"type": "ByteArray", "value": ""},
+				{"type": "ByteArray", "value": "59d2cef5a70f591516cb5888de225d9b4e8edc5e"},
+				{"type": "Integer", "value": "5"}
+			]}},
+			{"contract": "0xc88acaae8a0362cdbdedddf0083c452a3a8bb7b8", "state": {"type": "Array", "value": [
+				{"type": "ByteArray", "value": "617070726f7665"}
+			]}}
+		]
+	}]
+}`
+
+func TestPlugins(t *testing.T) {
+	var log interface{}
+	json.Unmarshal([]byte(testApplicationLog), &log)
+	node := testNode(t, map[string]interface{}{
+		"getapplicationlog": log,
+		"getnep5balances": map[string]interface{}{
+			"address": "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+			"balance": []interface{}{map[string]interface{}{
+				"asset_hash":         "c88acaae8a0362cdbdedddf0083c452a3a8bb7b8",
+				"amount":             "100000000000000000000",
+				"last_updated_block": 2000,
+			}},
+		},
+		"getclaimable": map[string]interface{}{
+			"address": "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+			"claimable": []interface{}{map[string]interface{}{
+				"txid": "0xb80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", "n": 1, "value": 10,
+				"start_height": 100, "end_height": 200, "generated": 0.00008, "sys_fee": 0.0000001, "unclaimed": 0.0000801,
+			}},
+			"unclaimed": 0.0000801,
+		},
+		"getnep5transfers": map[string]interface{}{
+			"address":  "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+			"sent":     []interface{}{},
+			"received": []interface{}{map[string]interface{}{"asset_hash": "c88acaae8a0362cdbdedddf0083c452a3a8bb7b8", "amount": "5", "tx_hash": "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830"}},
+		},
+	}, func(method string, params []json.RawMessage) {
+		if method == "getnep5transfers" && len(params) != 3 {
+			t.Errorf("getnep5transfers params %s", params)
+		}
+	})
+	defer node.Close()
+
+	ctx := context.Background()
+	c := NewClient(node.URL)
+
+	balances, err := c.GetNep5Balances(ctx, "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7")
+	if err != nil || balances.Balance[0].Amount.String() != "100000000000000000000" || balances.Balance[0].AssetHash.String() != "c88acaae8a0362cdbdedddf0083c452a3a8bb7b8" {
+		t.Fatalf("getnep5balances %+v %v", balances, err)
+	}
+	transfers, err := c.GetNep5Transfers(ctx, "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7", 0, 1600000000)
+	if err != nil || len(transfers.Received) != 1 || transfers.Received[0].Amount.Int64() != 5 {
+		t.Fatalf("getnep5transfers %+v %v", transfers, err)
+	}
+
+	claimable, err := c.GetClaimable(ctx, "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7")
+	if err != nil || claimable.Unclaimed != 8010 || claimable.Claimable[0].Value != 10*Fixed8(neo.D) || claimable.Claimable[0].EndHeight != 200 {
+		t.Fatalf("getclaimable %+v %v", claimable, err)
+	}
+	coin := claimable.Claimable[0].GasCoin()
+	if generated := coin.Value / neo.D * neo.GeneratedGas(coin.StartHeight, coin.EndHeight); generated != uint64(claimable.Claimable[0].Generated) {
+		t.Fatalf("generated GAS %d", generated)
+	}
+	claims := claimable.Claims()
+	if len(claims) != 1 || claims[0] != (neo.Utxo{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 8010, N: 1}) {
+		t.Fatalf("claims %+v", claims)
+	}
+
+	txid, _ := utils.Uint256DecodeString("b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830")
+	applog, err := c.GetApplicationLog(ctx, txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := applog.Nep5Transfers()
+	if len(events) != 2 {
+		t.Fatalf("transfer events %+v", events)
+	}
+	if events[0].From != "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7" || events[0].To != "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc" ||
+		events[0].Amount.Int64() != 100000000 || !events[0].TxId.Equals(txid) ||
+		events[0].Contract.String() != "c88acaae8a0362cdbdedddf0083c452a3a8bb7b8" {
+		t.Fatalf("transfer event %+v", events[0])
+	}
+	if events[1].From != "" || events[1].Amount.Int64() != 5 {
+		t.Fatalf("mint event %+v", events[1])
+	}
+
+	applog.Executions[0].VMState = "FAULT"
+	if len(applog.Nep5Transfers()) != 0 {
+		t.Fatal("transfers of a FAULT execution")
+	}
+}
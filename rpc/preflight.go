@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+)
+
+// FaultError is returned by Preflight when the script of a transaction
+// does not halt on the node.
+type FaultError struct {
+	Result *InvokeResult
+}
+
+func (e *FaultError) Error() string {
+	return fmt.Sprintf("preflight: script ended in state %s", e.Result.State)
+}
+
+// Preflight test-invokes the script of an unsigned invocation transaction
+// on the node, before any GAS is spent on it. It fails with a *FaultError
+// when the script FAULTs. Otherwise it attaches the GAS the script consumed
+// beyond the free allowance, taking the difference with the attached fee
+// from the GAS change paid to change, and returns the invocation result.
+func (c *Client) Preflight(ctx context.Context, tx *neo.Transaction, change string) (*InvokeResult, error) {
+	if tx.GetType() != neo.InvocationTransaction {
+		return nil, errors.New("preflight: not an invocation transaction")
+	}
+	result, err := c.InvokeScript(ctx, tx.GetScript())
+	if err != nil {
+		return nil, err
+	}
+	if !result.Halted() {
+		return result, &FaultError{Result: result}
+	}
+	gas := vm.SystemFee(int64(result.GasConsumed))
+	if err := tx.SetGas(uint64(gas), change); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// PreflightAndSign runs Preflight and signs tx with the WIF private key only
+// when the script halts. The change goes back to the address of the key.
+func (c *Client) PreflightAndSign(ctx context.Context, tx *neo.Transaction, wif string) (*InvokeResult, error) {
+	privKey := &ecdsa.PrivateKey{}
+	if err := neo.PrivateFromWIF(privKey, wif); err != nil {
+		return nil, err
+	}
+	result, err := c.Preflight(ctx, tx, neo.PublicToAddress(&privKey.PublicKey))
+	if err != nil {
+		return result, err
+	}
+	return result, tx.Sign(wif)
+}
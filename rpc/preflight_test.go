@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// localNode stands in for a node, running invokescript in the emulator.
+func localNode(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+			Id     uint64   `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Method != "invokescript" {
+			t.Errorf("unexpected %s", req.Method)
+			return
+		}
+		script, _ := hex.DecodeString(req.Params[0])
+		result := vm.NewEngine(vm.Application, nil).Run(script)
+		state := "HALT"
+		if result.State != vm.HALT {
+			state = "FAULT"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": &InvokeResult{
+			Script:      script,
+			State:       state,
+			GasConsumed: Fixed8(result.GasConsumed),
+			Stack:       []StackItem{},
+		}})
+	}))
+}
+
+func TestPreflight(t *testing.T) {
+	node := localNode(t)
+	defer node.Close()
+	c := NewClient(node.URL)
+
+	// Count down from 4000: 12001 paid instructions, 12.001 GAS, a 3 GAS fee.
+	sb := &neo.ScriptBuilder{}
+	sb.EmitPushNumber(*big.NewInt(4000))
+	sb.Emit(opcode.DEC, nil)
+	sb.Emit(opcode.DUP, nil)
+	sb.EmitJump(opcode.JMPIF, -2)
+	sb.Emit(opcode.DROP, nil)
+
+	const wif = "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1"
+	const from = "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7"
+	params := &neo.CreateSignParams{
+		Version: 1,
+		From:    from,
+		AssetId: neo.GasAssetId,
+		Data:    sb.ToBytes(),
+		Gas:     1 * neo.D,
+		Utxos:   []neo.Utxo{{Hash: "d233d677aee8164cffc5ffa0699920d9dda9d4f5a8c23ca074641777e2a00f3b", Value: 5 * neo.D}},
+	}
+	tx, err := neo.BuildTx(neo.InvocationTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.PreflightAndSign(context.Background(), tx, wif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.GasConsumed != 1200100000 || tx.GetGas() != 3*neo.D ||
+		len(tx.GetOutputs()) != 1 || tx.GetOutputs()[0].GetValue() != 2*neo.D || len(tx.GetWitnesses()) != 1 {
+		t.Fatalf("preflight gas %d, outputs %d", tx.GetGas(), len(tx.GetOutputs()))
+	}
+
+	params.Data = []byte{opcode.THROW}
+	tx, err = neo.BuildTx(neo.InvocationTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.PreflightAndSign(context.Background(), tx, wif)
+	if _, ok := err.(*FaultError); !ok {
+		t.Fatalf("expected FAULT, got %v", err)
+	}
+	if len(tx.GetWitnesses()) != 0 {
+		t.Fatal("FAULTing transaction was signed")
+	}
+
+	// A cheaper script returns the excess fee to the change.
+	params.Data = []byte{opcode.PUSH1, opcode.DROP}
+	params.Gas = 1 * neo.D
+	params.Utxos[0].Value = 1 * neo.D
+	tx, _ = neo.BuildTx(neo.InvocationTransaction, params)
+	if _, err := c.Preflight(context.Background(), tx, from); err != nil {
+		t.Fatal(err)
+	}
+	if tx.GetGas() != 0 || len(tx.GetOutputs()) != 1 || tx.GetOutputs()[0].GetValue() != 1*neo.D {
+		t.Fatal("preflight refund error")
+	}
+}
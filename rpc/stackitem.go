@@ -0,0 +1,239 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"math/big"
+)
+
+// MapEntry is a key and value of a Map stack item.
+type MapEntry struct {
+	Key   StackItem `json:"key"`
+	Value StackItem `json:"value"`
+}
+
+func (item *StackItem) boolean() (bool, error) {
+	var b bool
+	if err := json.Unmarshal(item.Value, &b); err == nil {
+		return b, nil
+	}
+	// Some nodes send booleans as strings.
+	var s string
+	if err := json.Unmarshal(item.Value, &s); err != nil {
+		return false, fmt.Errorf("invalid Boolean %s", item.Value)
+	}
+	return s == "true" || s == "True", nil
+}
+
+// GetByteArray returns the bytes of a ByteArray, or the little-endian
+// encoding of an Integer or Boolean.
+func (item *StackItem) GetByteArray() ([]byte, error) {
+	switch item.Type {
+	case "ByteArray":
+		var s string
+		if err := json.Unmarshal(item.Value, &s); err != nil {
+			return nil, err
+		}
+		return hex.DecodeString(s)
+	case "Integer", "Boolean":
+		v, err := item.GetBigInteger()
+		if err != nil {
+			return nil, err
+		}
+		return utils.BigIntToBytes(v), nil
+	}
+	return nil, fmt.Errorf("can not convert %s to ByteArray", item.Type)
+}
+
+// GetBigInteger returns the value of an Integer, or a ByteArray read as a
+// little-endian two's complement integer.
+func (item *StackItem) GetBigInteger() (*big.Int, error) {
+	switch item.Type {
+	case "Integer":
+		var s string
+		if err := json.Unmarshal(item.Value, &s); err != nil {
+			// Some nodes send integers as JSON numbers.
+			s = string(item.Value)
+		}
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid Integer %s", item.Value)
+		}
+		return v, nil
+	case "ByteArray":
+		data, err := item.GetByteArray()
+		if err != nil {
+			return nil, err
+		}
+		return utils.BytesToBigInt(data), nil
+	case "Boolean":
+		b, err := item.boolean()
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return big.NewInt(1), nil
+		}
+		return big.NewInt(0), nil
+	}
+	return nil, fmt.Errorf("can not convert %s to Integer", item.Type)
+}
+
+// GetBoolean returns the value of a Boolean, or whether an Integer or
+// ByteArray is non-zero, as the NeoVM converts them.
+func (item *StackItem) GetBoolean() (bool, error) {
+	switch item.Type {
+	case "Boolean":
+		return item.boolean()
+	case "Integer":
+		v, err := item.GetBigInteger()
+		if err != nil {
+			return false, err
+		}
+		return v.Sign() != 0, nil
+	case "ByteArray":
+		data, err := item.GetByteArray()
+		if err != nil {
+			return false, err
+		}
+		for _, b := range data {
+			if b != 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "Array", "Struct", "Map", "InteropInterface":
+		return true, nil
+	}
+	return false, fmt.Errorf("can not convert %s to Boolean", item.Type)
+}
+
+// GetString returns a ByteArray as a UTF-8 string.
+func (item *StackItem) GetString() (string, error) {
+	data, err := item.GetByteArray()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetUint160 returns a 20 byte ByteArray as a script hash.
+func (item *StackItem) GetUint160() (utils.Uint160, error) {
+	data, err := item.GetByteArray()
+	if err != nil {
+		return utils.Uint160{}, err
+	}
+	return utils.Uint160DecodeBytes(utils.BytesReverse(data))
+}
+
+// GetAddress returns a 20 byte ByteArray as the address of the script hash.
+func (item *StackItem) GetAddress() (string, error) {
+	hash, err := item.GetUint160()
+	if err != nil {
+		return "", err
+	}
+	return neo.ScriptHashToAddress(hash), nil
+}
+
+// GetArray returns the items of an Array or Struct.
+func (item *StackItem) GetArray() ([]StackItem, error) {
+	if item.Type != "Array" && item.Type != "Struct" {
+		return nil, fmt.Errorf("can not convert %s to Array", item.Type)
+	}
+	var items []StackItem
+	if err := json.Unmarshal(item.Value, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetMap returns the entries of a Map.
+func (item *StackItem) GetMap() ([]MapEntry, error) {
+	if item.Type != "Map" {
+		return nil, fmt.Errorf("can not convert %s to Map", item.Type)
+	}
+	var entries []MapEntry
+	if err := json.Unmarshal(item.Value, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ToVM converts item into a NeoVM stack item, so results of test
+// invocations decode the same way as results of the local emulator.
+// An InteropInterface becomes an empty one, since its value stays on the node.
+func (item *StackItem) ToVM() (vm.StackItem, error) {
+	switch item.Type {
+	case "ByteArray":
+		data, err := item.GetByteArray()
+		if err != nil {
+			return nil, err
+		}
+		return vm.NewByteArray(data), nil
+	case "Integer":
+		v, err := item.GetBigInteger()
+		if err != nil {
+			return nil, err
+		}
+		return vm.NewInteger(v), nil
+	case "Boolean":
+		b, err := item.boolean()
+		if err != nil {
+			return nil, err
+		}
+		return vm.NewBoolean(b), nil
+	case "Array", "Struct":
+		items, err := item.GetArray()
+		if err != nil {
+			return nil, err
+		}
+		vmItems := make([]vm.StackItem, len(items))
+		for i := range items {
+			if vmItems[i], err = items[i].ToVM(); err != nil {
+				return nil, err
+			}
+		}
+		if item.Type == "Struct" {
+			return vm.NewStruct(vmItems), nil
+		}
+		return vm.NewArray(vmItems), nil
+	case "Map":
+		entries, err := item.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		m := vm.NewMap()
+		for _, e := range entries {
+			key, err := e.Key.ToVM()
+			if err != nil {
+				return nil, err
+			}
+			value, err := e.Value.ToVM()
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, value)
+		}
+		return m, nil
+	case "InteropInterface":
+		return vm.NewInteropInterface(nil), nil
+	}
+	return nil, fmt.Errorf("unknown stack item type %s", item.Type)
+}
+
+// VMStack converts the result stack into NeoVM stack items, in the order
+// the node returns them, the first result first.
+func (r *InvokeResult) VMStack() ([]vm.StackItem, error) {
+	items := make([]vm.StackItem, len(r.Stack))
+	for i := range r.Stack {
+		var err error
+		if items[i], err = r.Stack[i].ToVM(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/vm"
+	"testing"
+)
+
+const testStack = `[
+	{"type": "ByteArray", "value": "00e1f505"},
+	{"type": "ByteArray", "value": "ff"},
+	{"type": "Integer", "value": "-7"},
+	{"type": "Boolean", "value": true},
+	{"type": "ByteArray", "value": "4e454f"},
+	{"type": "ByteArray", "value": "6bc68ea188bf198f4a4dd8aedc0ffd0f28677fad"},
+	{"type": "Array", "value": [{"type": "Integer", "value": "1"}, {"type": "Array", "value": [{"type": "Boolean", "value": false}]}]},
+	{"type": "Map", "value": [{"key": {"type": "ByteArray", "value": "6b6579"}, "value": {"type": "Integer", "value": "2"}}]},
+	{"type": "InteropInterface"}
+]`
+
+func TestStackItem(t *testing.T) {
+	result := &InvokeResult{}
+	if err := json.Unmarshal([]byte(`{"state": "HALT", "stack": `+testStack+`}`), result); err != nil {
+		t.Fatal(err)
+	}
+	s := result.Stack
+
+	if v, err := s[0].GetBigInteger(); err != nil || v.Int64() != 100000000 {
+		t.Fatalf("ByteArray integer %v %v", v, err)
+	}
+	if v, err := s[1].GetBigInteger(); err != nil || v.Int64() != -1 {
+		t.Fatalf("negative ByteArray integer %v %v", v, err)
+	}
+	if data, err := s[2].GetByteArray(); err != nil || len(data) != 1 || data[0] != 0xf9 {
+		t.Fatalf("Integer bytes %x %v", data, err)
+	}
+	if b, err := s[3].GetBoolean(); err != nil || !b {
+		t.Fatalf("Boolean %v %v", b, err)
+	}
+	if str, err := s[4].GetString(); err != nil || str != "NEO" {
+		t.Fatalf("string %s %v", str, err)
+	}
+	if address, err := s[5].GetAddress(); err != nil || address != "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7" {
+		t.Fatalf("address %s %v", address, err)
+	}
+	if _, err := s[4].GetUint160(); err == nil {
+		t.Fatal("expected Uint160 length error")
+	}
+
+	items, err := s[6].GetArray()
+	if err != nil || len(items) != 2 {
+		t.Fatalf("array %v %v", items, err)
+	}
+	nested, err := items[1].GetArray()
+	if b, _ := nested[0].GetBoolean(); err != nil || b {
+		t.Fatal("nested array error")
+	}
+	entries, err := s[7].GetMap()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("map %v %v", entries, err)
+	}
+	if key, _ := entries[0].Key.GetString(); key != "key" {
+		t.Fatal("map key error")
+	}
+	if _, err := s[7].GetBigInteger(); err == nil {
+		t.Fatal("expected Map conversion error")
+	}
+
+	stack, err := result.VMStack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := stack[7].(*vm.Map)
+	if v, ok := m.Get(vm.NewByteArray([]byte("key"))); !ok || v.GetBigInteger().Int64() != 2 {
+		t.Fatal("vm map error")
+	}
+	if stack[6].(*vm.Array).Items[1].(*vm.Array).Items[0].GetBoolean() {
+		t.Fatal("vm nested array error")
+	}
+	if _, ok := stack[8].(*vm.InteropInterface); !ok {
+		t.Fatal("vm interop interface error")
+	}
+}
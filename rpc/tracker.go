@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"time"
+)
+
+var (
+	// ErrTimeout is the reason of a transaction dropped after Tracker.Timeout.
+	ErrTimeout = errors.New("transaction not confirmed in time")
+	// ErrInputSpent is the reason of a transaction dropped because another
+	// transaction spent one of its inputs.
+	ErrInputSpent = errors.New("transaction input spent by another transaction")
+	// ErrRejected is the reason of a transaction the node refused to relay.
+	ErrRejected = errors.New("transaction rejected by the node")
+)
+
+// alreadyExists is the error code of a node asked to relay a transaction it
+// already has.
+const alreadyExists = -501
+
+// TrackStatus is the final state of a tracked transaction.
+type TrackStatus int
+
+const (
+	Confirmed TrackStatus = iota
+	Dropped
+)
+
+func (s TrackStatus) String() string {
+	if s == Confirmed {
+		return "confirmed"
+	}
+	return "dropped"
+}
+
+// TrackResult is the outcome of tracking a transaction. Height is the index
+// of the block confirming it; Err is the reason it was dropped.
+type TrackResult struct {
+	TxId   utils.Uint256
+	Status TrackStatus
+	Height uint32
+	Err    error
+}
+
+// Tracker broadcasts transactions and follows them until they are confirmed
+// or dropped.
+type Tracker struct {
+	Client *Client
+	// PollInterval is how often the chain is polled for new blocks.
+	PollInterval time.Duration
+	// RebroadcastInterval is how often a transaction missing from the memory
+	// pool of the node is sent again.
+	RebroadcastInterval time.Duration
+	// Timeout is how long a transaction may stay unconfirmed.
+	Timeout time.Duration
+}
+
+// NewTracker returns a tracker using c with intervals suited to the 15
+// second blocks of NEO 2.x.
+func NewTracker(c *Client) *Tracker {
+	return &Tracker{
+		Client:              c,
+		PollInterval:        5 * time.Second,
+		RebroadcastInterval: time.Minute,
+		Timeout:             10 * time.Minute,
+	}
+}
+
+// Track broadcasts the serialized transaction raw with hash txid and returns
+// a channel receiving its TrackResult. Cancelling ctx stops tracking without
+// a result.
+func (t *Tracker) Track(ctx context.Context, raw []byte, txid utils.Uint256) <-chan TrackResult {
+	ch := make(chan TrackResult, 1)
+	go func() {
+		if result, ok := t.track(ctx, raw, txid); ok {
+			ch <- result
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// TrackFunc is Track calling fn with the result instead of sending it on a channel.
+func (t *Tracker) TrackFunc(ctx context.Context, raw []byte, txid utils.Uint256, fn func(TrackResult)) {
+	go func() {
+		if result, ok := t.track(ctx, raw, txid); ok {
+			fn(result)
+		}
+	}()
+}
+
+func (t *Tracker) track(ctx context.Context, raw []byte, txid utils.Uint256) (TrackResult, bool) {
+	var inputs []neo.TransactionInput
+	if tx, err := neo.DecodeTransaction(raw); err == nil {
+		inputs = tx.GetInputs()
+	}
+	result := TrackResult{TxId: txid, Status: Dropped}
+
+	deadline := time.Now().Add(t.Timeout)
+	if err := t.broadcast(ctx, raw); err != nil {
+		if ctx.Err() != nil {
+			return result, false
+		}
+		result.Err = err
+		return result, true
+	}
+	lastBroadcast := time.Now()
+
+	ticker := time.NewTicker(t.PollInterval)
+	defer ticker.Stop()
+	var lastHeight uint32
+	for {
+		select {
+		case <-ctx.Done():
+			return result, false
+		case <-ticker.C:
+		}
+
+		height, err := t.Client.GetBlockCount(ctx)
+		if err == nil && height != lastHeight {
+			lastHeight = height
+			if confirmed, ok := t.confirmation(ctx, txid); ok {
+				result.Status, result.Height = Confirmed, confirmed
+				return result, true
+			}
+			if t.inputSpent(ctx, inputs) {
+				// Our own transaction may have been confirmed in the meantime.
+				if confirmed, ok := t.confirmation(ctx, txid); ok {
+					result.Status, result.Height = Confirmed, confirmed
+					return result, true
+				}
+				result.Err = ErrInputSpent
+				return result, true
+			}
+		}
+
+		if time.Now().After(deadline) {
+			result.Err = ErrTimeout
+			return result, true
+		}
+		if time.Since(lastBroadcast) < t.RebroadcastInterval || t.inMemPool(ctx, txid) {
+			continue
+		}
+		// A transaction leaves the memory pool when it is confirmed, too.
+		if confirmed, ok := t.confirmation(ctx, txid); ok {
+			result.Status, result.Height = Confirmed, confirmed
+			return result, true
+		}
+		t.Client.SendRawTransaction(ctx, raw)
+		lastBroadcast = time.Now()
+	}
+}
+
+// broadcast sends raw to the node, returning an error if the node rejects it.
+// A transaction the node already has, or one it could not be reached for, is
+// left to rebroadcasting.
+func (t *Tracker) broadcast(ctx context.Context, raw []byte) error {
+	ok, err := t.Client.SendRawTransaction(ctx, raw)
+	if e, isNodeError := err.(*Error); isNodeError {
+		if e.Code == alreadyExists {
+			return nil
+		}
+		return e
+	}
+	if err == nil && !ok {
+		return ErrRejected
+	}
+	return nil
+}
+
+// confirmation returns the index of the block confirming txid, if any.
+func (t *Tracker) confirmation(ctx context.Context, txid utils.Uint256) (uint32, bool) {
+	tx, err := t.Client.GetTransaction(ctx, txid)
+	if err != nil || tx.Confirmations == 0 {
+		return 0, false
+	}
+	block, err := t.Client.GetBlock(ctx, tx.BlockHash)
+	if err != nil {
+		return 0, false
+	}
+	return block.Index, true
+}
+
+// inputSpent reports whether one of inputs is spent.
+func (t *Tracker) inputSpent(ctx context.Context, inputs []neo.TransactionInput) bool {
+	for _, input := range inputs {
+		output, err := t.Client.GetTxOut(ctx, input.GetHash(), input.GetIndex())
+		if err == nil && output == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tracker) inMemPool(ctx context.Context, txid utils.Uint256) bool {
+	hashes, err := t.Client.GetRawMemPool(ctx)
+	if err != nil {
+		// Assume it is there rather than flooding a failing node.
+		return true
+	}
+	for _, hash := range hashes {
+		if hash.Equals(txid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chainNode stands in for a node whose chain and memory pool the test
+// changes while a transaction is tracked.
+type chainNode struct {
+	mu sync.Mutex
+	// height is the block count, confirmed the index of the block holding
+	// the transaction when non-zero.
+	height    uint32
+	confirmed uint32
+	mempool   bool
+	// evict drops relayed transactions from the memory pool; reject is the
+	// error answering sendrawtransaction.
+	evict  bool
+	reject *Error
+	spent  bool
+	sends  int
+}
+
+func (n *chainNode) update(fn func(n *chainNode)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	fn(n)
+}
+
+// wait blocks until cond holds for n.
+func (n *chainNode) wait(t *testing.T, cond func(n *chainNode) bool) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		n.mu.Lock()
+		ok := cond(n)
+		n.mu.Unlock()
+		if ok {
+			return
+		}
+	}
+	t.Fatal("condition not reached")
+}
+
+func (n *chainNode) sent() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.sends
+}
+
+func (n *chainNode) serve(t *testing.T, txid string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Id     uint64 `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		n.mu.Lock()
+		var result interface{}
+		switch req.Method {
+		case "sendrawtransaction":
+			n.sends++
+			if n.reject != nil {
+				result = n.reject
+			} else {
+				n.mempool = !n.evict
+				result = true
+			}
+		case "getblockcount":
+			result = n.height
+		case "getrawtransaction":
+			if n.confirmed != 0 {
+				result = map[string]interface{}{"txid": txid, "blockhash": "0x" + txid, "confirmations": n.height - n.confirmed}
+			} else if n.mempool {
+				result = map[string]interface{}{"txid": txid}
+			} else {
+				result = &Error{Code: -100, Message: "Unknown transaction"}
+			}
+		case "getblock":
+			result = map[string]interface{}{"index": n.confirmed}
+		case "gettxout":
+			if !n.spent {
+				result = map[string]interface{}{"n": 0, "asset": "0x" + neo.NeoAssetId, "value": "10"}
+			}
+		case "getrawmempool":
+			result = []string{}
+			if n.mempool {
+				result = []string{txid}
+			}
+		}
+		n.mu.Unlock()
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id}
+		if err, ok := result.(*Error); ok {
+			resp["error"] = err
+		} else {
+			resp["result"] = result
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestTracker(t *testing.T) {
+	params := &neo.CreateSignParams{
+		Version: 1,
+		PriKey:  "L4RmQvd6PVzBTgYLpYagknNjhZxsHBbJq4ky7Zd3vB7AguSM7gF1",
+		From:    "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7",
+		To:      "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc",
+		AssetId: neo.NeoAssetId,
+		Value:   1 * neo.D,
+		Utxos:   []neo.Utxo{{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: 10 * neo.D}},
+	}
+	_, rawHex, err := neo.CreateTx(neo.ContractTransaction, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := hex.DecodeString(rawHex)
+	tx, err := neo.DecodeTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := tx.GetTxId()
+
+	track := func(n *chainNode, timeout time.Duration) (<-chan TrackResult, func()) {
+		node := n.serve(t, txid.String())
+		tracker := NewTracker(NewClient(node.URL))
+		tracker.PollInterval = 5 * time.Millisecond
+		tracker.RebroadcastInterval = 20 * time.Millisecond
+		tracker.Timeout = timeout
+		return tracker.Track(context.Background(), raw, txid), node.Close
+	}
+
+	// Confirmed in a block after sitting in the memory pool past the
+	// rebroadcast interval: it leaves the pool as it is confirmed.
+	n := &chainNode{height: 100}
+	results, closeNode := track(n, 5*time.Second)
+	n.wait(t, func(n *chainNode) bool { return n.mempool })
+	time.Sleep(30 * time.Millisecond)
+	n.update(func(n *chainNode) { n.height, n.confirmed, n.mempool = 102, 100, false })
+	result := <-results
+	closeNode()
+	if result.Status != Confirmed || result.Height != 100 || !result.TxId.Equals(txid) {
+		t.Fatalf("confirmed result %+v", result)
+	}
+	if sends := n.sent(); sends != 1 {
+		t.Fatalf("transaction in the memory pool sent %d times", sends)
+	}
+
+	// Missing from the memory pool, rebroadcast until its input is spent elsewhere.
+	n = &chainNode{height: 100, evict: true}
+	results, closeNode = track(n, 5*time.Second)
+	n.wait(t, func(n *chainNode) bool { return n.sends >= 2 })
+	n.update(func(n *chainNode) { n.height, n.spent = 101, true })
+	result = <-results
+	closeNode()
+	if result.Status != Dropped || result.Err != ErrInputSpent {
+		t.Fatalf("double spent result %+v", result)
+	}
+
+	// Rejected by the node.
+	n = &chainNode{height: 100, reject: &Error{Code: -504, Message: "Invalid"}}
+	results, closeNode = track(n, 5*time.Second)
+	result = <-results
+	closeNode()
+	if e, ok := result.Err.(*Error); result.Status != Dropped || !ok || e.Code != -504 || n.sent() != 1 {
+		t.Fatalf("rejected result %+v", result)
+	}
+
+	// Already known to the node, so still tracked.
+	n = &chainNode{height: 100, confirmed: 99, reject: &Error{Code: alreadyExists, Message: "AlreadyExists"}}
+	results, closeNode = track(n, 5*time.Second)
+	result = <-results
+	closeNode()
+	if result.Status != Confirmed || result.Height != 99 {
+		t.Fatalf("already known result %+v", result)
+	}
+
+	// Never confirmed.
+	n = &chainNode{height: 100}
+	results, closeNode = track(n, 30*time.Millisecond)
+	result = <-results
+	closeNode()
+	if result.Status != Dropped || result.Err != ErrTimeout {
+		t.Fatalf("timeout result %+v", result)
+	}
+
+	// Cancelled tracking closes the channel without a result.
+	n = &chainNode{height: 100}
+	node := n.serve(t, txid.String())
+	defer node.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	results = NewTracker(NewClient(node.URL)).Track(ctx, raw, utils.Uint256{})
+	cancel()
+	if _, ok := <-results; ok {
+		t.Fatal("cancelled tracking sent a result")
+	}
+}
@@ -0,0 +1,249 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"math/big"
+	"strings"
+)
+
+// Fixed8 is an amount in units of 10^-8, which nodes send as a decimal string.
+type Fixed8 int64
+
+func (f Fixed8) String() string {
+	return neo.FormatAmount(big.NewInt(int64(f)), 8)
+}
+
+func (f Fixed8) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+func (f *Fixed8) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	// Plugins and indexers also send plain JSON numbers, possibly with an exponent.
+	r, ok := new(big.Rat).SetString(s)
+	if ok {
+		r.Mul(r, big.NewRat(int64(neo.D), 1))
+	}
+	if !ok || !r.IsInt() || !r.Num().IsInt64() {
+		return fmt.Errorf("invalid Fixed8 %s", data)
+	}
+	*f = Fixed8(r.Num().Int64())
+	return nil
+}
+
+// HexBytes is a byte array sent as a hex string.
+type HexBytes []byte
+
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// BigInt is an integer sent as a decimal string.
+type BigInt struct {
+	big.Int
+}
+
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *BigInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if _, ok := b.SetString(s, 10); !ok {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	return nil
+}
+
+type Input struct {
+	TxId utils.Uint256 `json:"txid"`
+	Vout uint16        `json:"vout"`
+}
+
+type Output struct {
+	N       uint16        `json:"n"`
+	Asset   utils.Uint256 `json:"asset"`
+	Value   Fixed8        `json:"value"`
+	Address string        `json:"address"`
+}
+
+type Attribute struct {
+	Usage string `json:"usage"`
+	Data  string `json:"data"`
+}
+
+type Script struct {
+	Invocation   HexBytes `json:"invocation"`
+	Verification HexBytes `json:"verification"`
+}
+
+// Transaction is a transaction as getrawtransaction and getblock return
+// it in verbose mode. Block fields are empty for unconfirmed transactions.
+type Transaction struct {
+	TxId       utils.Uint256 `json:"txid"`
+	Size       int           `json:"size"`
+	Type       string        `json:"type"`
+	Version    int           `json:"version"`
+	Attributes []Attribute   `json:"attributes"`
+	Vin        []Input       `json:"vin"`
+	Vout       []Output      `json:"vout"`
+	SysFee     Fixed8        `json:"sys_fee"`
+	NetFee     Fixed8        `json:"net_fee"`
+	Scripts    []Script      `json:"scripts"`
+
+	Nonce  uint32   `json:"nonce,omitempty"`
+	Claims []Input  `json:"claims,omitempty"`
+	Script HexBytes `json:"script,omitempty"`
+	Gas    Fixed8   `json:"gas,omitempty"`
+
+	BlockHash     utils.Uint256 `json:"blockhash"`
+	Confirmations uint32        `json:"confirmations"`
+	BlockTime     uint32        `json:"blocktime"`
+}
+
+// Block is a block as getblock returns it in verbose mode.
+type Block struct {
+	Hash              utils.Uint256 `json:"hash"`
+	Size              int           `json:"size"`
+	Version           uint32        `json:"version"`
+	PreviousBlockHash utils.Uint256 `json:"previousblockhash"`
+	MerkleRoot        utils.Uint256 `json:"merkleroot"`
+	Time              uint32        `json:"time"`
+	Index             uint32        `json:"index"`
+	Nonce             string        `json:"nonce"`
+	NextConsensus     string        `json:"nextconsensus"`
+	Script            Script        `json:"script"`
+	Tx                []Transaction `json:"tx"`
+	Confirmations     uint32        `json:"confirmations"`
+	NextBlockHash     utils.Uint256 `json:"nextblockhash"`
+}
+
+type Balance struct {
+	Asset utils.Uint256 `json:"asset"`
+	Value Fixed8        `json:"value"`
+}
+
+type AccountState struct {
+	Version    int           `json:"version"`
+	ScriptHash utils.Uint160 `json:"script_hash"`
+	Frozen     bool          `json:"frozen"`
+	Votes      []string      `json:"votes"`
+	Balances   []Balance     `json:"balances"`
+}
+
+// Balance returns the balance of asset, zero when the account has none.
+func (s *AccountState) Balance(asset utils.Uint256) Fixed8 {
+	for _, b := range s.Balances {
+		if b.Asset.Equals(asset) {
+			return b.Value
+		}
+	}
+	return 0
+}
+
+type AssetName struct {
+	Lang string `json:"lang"`
+	Name string `json:"name"`
+}
+
+type AssetState struct {
+	Version    int           `json:"version"`
+	Id         utils.Uint256 `json:"id"`
+	Type       string        `json:"type"`
+	Name       []AssetName   `json:"name"`
+	Amount     Fixed8        `json:"amount"`
+	Available  Fixed8        `json:"available"`
+	Precision  int           `json:"precision"`
+	Owner      string        `json:"owner"`
+	Admin      string        `json:"admin"`
+	Issuer     string        `json:"issuer"`
+	Expiration uint32        `json:"expiration"`
+	Frozen     bool          `json:"frozen"`
+}
+
+type ContractProperties struct {
+	Storage       bool `json:"storage"`
+	DynamicInvoke bool `json:"dynamic_invoke"`
+}
+
+type ContractState struct {
+	Version     int                `json:"version"`
+	Hash        utils.Uint160      `json:"hash"`
+	Script      HexBytes           `json:"script"`
+	Parameters  []string           `json:"parameters"`
+	ReturnType  string             `json:"returntype"`
+	Name        string             `json:"name"`
+	CodeVersion string             `json:"code_version"`
+	Author      string             `json:"author"`
+	Email       string             `json:"email"`
+	Description string             `json:"description"`
+	Properties  ContractProperties `json:"properties"`
+}
+
+// StackItem is a stack item as invokescript returns it.
+type StackItem struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// InvokeResult is the result of a test invocation.
+type InvokeResult struct {
+	Script      HexBytes    `json:"script"`
+	State       string      `json:"state"`
+	GasConsumed Fixed8      `json:"gas_consumed"`
+	Stack       []StackItem `json:"stack"`
+	Tx          HexBytes    `json:"tx,omitempty"`
+}
+
+// Halted reports whether the invocation ran to completion.
+func (r *InvokeResult) Halted() bool {
+	return strings.Contains(r.State, "HALT") && !strings.Contains(r.State, "FAULT")
+}
+
+// Param is a contract parameter of invokefunction. Values are Go values of
+// the parameter type: bool, integers or *big.Int, utils.Uint160,
+// utils.Uint256, []byte, string, or []Param for arrays.
+type Param struct {
+	Type  neo.ContractParameterType
+	Value interface{}
+}
+
+func (p Param) MarshalJSON() ([]byte, error) {
+	value := p.Value
+	switch v := p.Value.(type) {
+	case []byte:
+		value = hex.EncodeToString(v)
+	case *big.Int:
+		value = v.String()
+	case int, int32, int64, uint, uint32, uint64:
+		value = fmt.Sprint(v)
+	case utils.Uint160:
+		value = v.String()
+	case utils.Uint256:
+		value = v.String()
+	}
+	return json.Marshal(&struct {
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	}{p.Type.String(), value})
+}
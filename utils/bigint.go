@@ -0,0 +1,43 @@
+package utils
+
+import "math/big"
+
+// BigIntToBytes encodes v as a minimal little-endian two's complement byte
+// slice, the way NEO and the NeoVM store integers. Zero encodes to an empty slice.
+func BigIntToBytes(v *big.Int) []byte {
+	switch v.Sign() {
+	case 0:
+		return []byte{}
+	case 1:
+		data := BytesReverse(v.Bytes())
+		if data[len(data)-1]&0x80 != 0 {
+			data = append(data, 0x00)
+		}
+		return data
+	}
+
+	// -v-1 is non-negative; inverting its bytes yields the two's complement of v.
+	x := new(big.Int).Neg(v)
+	x.Sub(x, big.NewInt(1))
+	data := BytesReverse(x.Bytes())
+	for i := range data {
+		data[i] = ^data[i]
+	}
+	if len(data) == 0 || data[len(data)-1]&0x80 == 0 {
+		data = append(data, 0xff)
+	}
+	return data
+}
+
+// BytesToBigInt decodes a little-endian two's complement byte slice.
+func BytesToBigInt(data []byte) *big.Int {
+	v := new(big.Int)
+	if len(data) == 0 {
+		return v
+	}
+	v.SetBytes(BytesReverse(data))
+	if data[len(data)-1]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+	}
+	return v
+}
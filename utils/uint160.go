@@ -3,8 +3,10 @@ package utils
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"golang.org/x/crypto/ripemd160"
+	"strings"
 )
 
 const uint160Size = 20
@@ -74,3 +76,26 @@ func (u Uint160) Equals(other Uint160) bool {
 	}
 	return true
 }
+
+// MarshalJSON encodes u as a 0x prefixed hex string, as NEO nodes do.
+func (u Uint160) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + u.String())
+}
+
+// UnmarshalJSON decodes a hex string, with or without the 0x prefix. A
+// null leaves u unchanged.
+func (u *Uint160) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := Uint160DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
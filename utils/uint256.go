@@ -2,7 +2,9 @@ package utils
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 const uint256Size = 32
@@ -57,3 +59,26 @@ func (u Uint256) Equals(other Uint256) bool {
 func (u Uint256) String() string {
 	return hex.EncodeToString(BytesReverse(u.Bytes()))
 }
+
+// MarshalJSON encodes u as a 0x prefixed hex string, as NEO nodes do.
+func (u Uint256) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + u.String())
+}
+
+// UnmarshalJSON decodes a hex string, with or without the 0x prefix. A
+// null leaves u unchanged.
+func (u *Uint256) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := Uint256DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
@@ -130,3 +130,43 @@ func ReadVarInt(buf *bytes.Buffer, max uint64) uint64 {
 
 	return value
 }
+
+// ReadBytes reads n bytes from buf, panicking when it holds fewer.
+func ReadBytes(buf *bytes.Buffer, n int) []byte {
+	if buf.Len() < n {
+		panic("runtime error: unexpected end of data")
+	}
+	data := make([]byte, n)
+	buf.Read(data)
+	return data
+}
+
+// ReadCount reads a varint count, panicking when it is truncated or above max.
+func ReadCount(buf *bytes.Buffer, max uint64) int {
+	var count uint64
+	switch prefix := ReadBytes(buf, 1)[0]; prefix {
+	case 0xfd:
+		count = uint64(binary.LittleEndian.Uint16(ReadBytes(buf, 2)))
+	case 0xfe:
+		count = uint64(binary.LittleEndian.Uint32(ReadBytes(buf, 4)))
+	case 0xff:
+		count = binary.LittleEndian.Uint64(ReadBytes(buf, 8))
+	default:
+		count = uint64(prefix)
+	}
+	if count > max {
+		panic("runtime error: count too large")
+	}
+	return int(count)
+}
+
+// ReadVarBytes reads bytes prefixed with their varint length, at most max.
+func ReadVarBytes(buf *bytes.Buffer, max uint64) []byte {
+	return ReadBytes(buf, ReadCount(buf, max))
+}
+
+// WriteVarBytes writes data prefixed with its varint length.
+func WriteVarBytes(buf *bytes.Buffer, data []byte) {
+	WriteVarInt(buf, uint64(len(data)))
+	buf.Write(data)
+}
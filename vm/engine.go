@@ -0,0 +1,1040 @@
+package vm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// State is the execution state of an engine.
+type State byte
+
+const (
+	NONE  State = 0
+	HALT  State = 1 << 0
+	FAULT State = 1 << 1
+	BREAK State = 1 << 2
+)
+
+func (s State) String() string {
+	if s == NONE {
+		return "NONE"
+	}
+	var names []string
+	if s&HALT != 0 {
+		names = append(names, "HALT")
+	}
+	if s&FAULT != 0 {
+		names = append(names, "FAULT")
+	}
+	if s&BREAK != 0 {
+		names = append(names, "BREAK")
+	}
+	return strings.Join(names, ", ")
+}
+
+// Triggers the engine can run scripts under.
+const (
+	Verification byte = 0x00
+	Application  byte = 0x10
+)
+
+// Limits enforced by NeoVM 2.x.
+const (
+	MaxStackSize           = 2 * 1024
+	MaxItemSize            = 1024 * 1024
+	MaxArraySize           = 1024
+	MaxInvocationStackSize = 1024
+	MaxSizeForBigInteger   = 32
+	MaxShift               = 256
+	MinShift               = -256
+	MaxStorageKeySize      = 1024
+)
+
+// Context is a script being executed, with its own evaluation and alt stack.
+type Context struct {
+	Script     []byte
+	ScriptHash utils.Uint160
+	IP         int
+	RVCount    int
+	Evaluation *Stack
+	Alt        *Stack
+}
+
+// InteropFunc implements an interop service called through SYSCALL.
+// Returning an error faults the engine.
+type InteropFunc func(e *Engine) error
+
+// Notification is an item sent through Runtime.Notify.
+type Notification struct {
+	ScriptHash utils.Uint160
+	State      StackItem
+}
+
+// Result is the outcome of running a script.
+type Result struct {
+	State State
+	// GasConsumed is in Fixed8 units, 100000000 being one GAS.
+	GasConsumed   int64
+	Stack         []StackItem
+	Notifications []Notification
+	Logs          []string
+	Err           error
+}
+
+// Engine is an in-process NeoVM 2.x with the application engine's GAS
+// accounting and a pluggable set of interop services.
+type Engine struct {
+	Trigger   byte
+	Container ScriptContainer
+	Contracts ContractProvider
+	Storage   StorageProvider
+	// GasLimit caps the GAS consumption in Fixed8 units; zero means no limit,
+	// the way nodes run invokescript.
+	GasLimit int64
+	// Witnesses are the script hashes Runtime.CheckWitness accepts.
+	Witnesses []utils.Uint160
+	Time      uint32
+	Height    uint32
+
+	services      map[string]InteropFunc
+	invocation    []*Context
+	results       Stack
+	state         State
+	gasConsumed   int64
+	notifications []Notification
+	logs          []string
+	err           error
+}
+
+// NewEngine returns an engine with in-memory contract and storage providers
+// and the default interop services registered.
+func NewEngine(trigger byte, container ScriptContainer) *Engine {
+	e := &Engine{
+		Trigger:   trigger,
+		Container: container,
+		Contracts: MemoryContracts{},
+		Storage:   MemoryStorage{},
+		services:  make(map[string]InteropFunc),
+	}
+	registerDefaultServices(e)
+	return e
+}
+
+// Register installs or replaces the interop service called name.
+func (e *Engine) Register(name string, fn InteropFunc) {
+	e.services[name] = fn
+}
+
+// LoadScript pushes script onto the invocation stack. rvcount is the number
+// of items returned to the caller, -1 meaning all of them.
+func (e *Engine) LoadScript(script []byte, rvcount int) *Context {
+	ctx := &Context{
+		Script:     script,
+		ScriptHash: ScriptHash(script),
+		RVCount:    rvcount,
+		Evaluation: &Stack{},
+		Alt:        &Stack{},
+	}
+	e.invocation = append(e.invocation, ctx)
+	return ctx
+}
+
+// CurrentContext returns the executing context.
+func (e *Engine) CurrentContext() *Context {
+	if len(e.invocation) == 0 {
+		return nil
+	}
+	return e.invocation[len(e.invocation)-1]
+}
+
+// CallingContext returns the context that called the executing one.
+func (e *Engine) CallingContext() *Context {
+	if len(e.invocation) < 2 {
+		return nil
+	}
+	return e.invocation[len(e.invocation)-2]
+}
+
+// EntryContext returns the first loaded context.
+func (e *Engine) EntryContext() *Context {
+	if len(e.invocation) == 0 {
+		return nil
+	}
+	return e.invocation[0]
+}
+
+// State returns the engine state.
+func (e *Engine) State() State {
+	return e.state
+}
+
+// GasConsumed returns the consumed GAS in Fixed8 units.
+func (e *Engine) GasConsumed() int64 {
+	return e.gasConsumed
+}
+
+// ResultStack returns the items left by the entry script.
+func (e *Engine) ResultStack() *Stack {
+	return &e.results
+}
+
+// Err returns the reason of a FAULT.
+func (e *Engine) Err() error {
+	return e.err
+}
+
+// Execute runs the loaded scripts until the engine halts or faults.
+func (e *Engine) Execute() State {
+	e.state &^= BREAK
+	for e.state&(HALT|FAULT) == 0 {
+		e.StepInto()
+	}
+	return e.state
+}
+
+// StepInto executes the next instruction.
+func (e *Engine) StepInto() {
+	if len(e.invocation) == 0 {
+		e.state |= HALT
+	}
+	if e.state&(HALT|FAULT) != 0 {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			e.state |= FAULT
+			if err, ok := r.(error); ok {
+				e.err = err
+			} else {
+				e.err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	ctx := e.CurrentContext()
+	start := ctx.IP
+	// Running off the end of a script is a free RET.
+	op, operand := opcode.RET, []byte(nil)
+	if ctx.IP < len(ctx.Script) {
+		var next int
+		var err error
+		op, operand, next, err = opcode.Decode(ctx.Script, ctx.IP)
+		if err != nil {
+			panic(err)
+		}
+		ctx.IP = next
+
+		e.gasConsumed += OpPrice(op, operand, ctx.Evaluation) * GasRatio
+		if e.GasLimit > 0 && e.gasConsumed > e.GasLimit {
+			panic("runtime error: gas limit exceeded")
+		}
+	}
+
+	e.executeOp(ctx, op, operand, start)
+	e.checkStackSize()
+
+	if len(e.invocation) == 0 {
+		e.state |= HALT
+	}
+}
+
+// Run loads script as the entry script, executes it and collects the result.
+func (e *Engine) Run(script []byte) *Result {
+	e.LoadScript(script, -1)
+	e.Execute()
+	return &Result{
+		State:         e.state,
+		GasConsumed:   e.gasConsumed,
+		Stack:         e.results.Items(),
+		Notifications: e.notifications,
+		Logs:          e.logs,
+		Err:           e.err,
+	}
+}
+
+// Push puts item on the evaluation stack of the executing context.
+func (e *Engine) Push(item StackItem) {
+	e.CurrentContext().Evaluation.Push(item)
+}
+
+// Pop removes the top item of the evaluation stack of the executing context.
+func (e *Engine) Pop() StackItem {
+	return e.CurrentContext().Evaluation.Pop()
+}
+
+// PopBytes pops an item as a byte array.
+func (e *Engine) PopBytes() []byte {
+	return e.Pop().GetByteArray()
+}
+
+// PopBigInteger pops an item as an integer.
+func (e *Engine) PopBigInteger() *big.Int {
+	item := e.Pop()
+	if b, ok := item.(*ByteArray); ok && len(b.Value) > MaxSizeForBigInteger {
+		panic("runtime error: integer too large")
+	}
+	return item.GetBigInteger()
+}
+
+// PopBoolean pops an item as a boolean.
+func (e *Engine) PopBoolean() bool {
+	return e.Pop().GetBoolean()
+}
+
+func (e *Engine) popInt() int {
+	v := e.PopBigInteger()
+	if !v.IsInt64() || v.Int64() > 0x7fffffff || v.Int64() < -0x80000000 {
+		panic("runtime error: integer out of range")
+	}
+	return int(v.Int64())
+}
+
+func (e *Engine) pushInt(v *big.Int) {
+	if len(utils.BigIntToBytes(v)) > MaxSizeForBigInteger {
+		panic("runtime error: integer too large")
+	}
+	e.Push(NewInteger(v))
+}
+
+func (e *Engine) pushBytes(data []byte) {
+	if len(data) > MaxItemSize {
+		panic("runtime error: item too large")
+	}
+	e.Push(NewByteArray(data))
+}
+
+func (e *Engine) checkStackSize() {
+	count := e.results.Count()
+	for _, ctx := range e.invocation {
+		count += ctx.Evaluation.Count() + ctx.Alt.Count()
+	}
+	if count > MaxStackSize {
+		panic("runtime error: stack too large")
+	}
+}
+
+func (e *Engine) jumpTarget(ctx *Context, base int, operand []byte) int {
+	offset := int(int16(uint16(operand[0]) | uint16(operand[1])<<8))
+	target := base + offset
+	if target < 0 || target > len(ctx.Script) {
+		panic("runtime error: jump out of script range")
+	}
+	return target
+}
+
+func (e *Engine) loadContract(hash utils.Uint160, rvcount int) *Context {
+	if e.Contracts == nil {
+		panic("runtime error: no contract provider")
+	}
+	contract, err := e.Contracts.GetContract(hash)
+	if err != nil {
+		panic(err)
+	}
+	if contract == nil {
+		panic(fmt.Errorf("runtime error: contract %s not found", hash))
+	}
+	if len(e.invocation) >= MaxInvocationStackSize {
+		panic("runtime error: invocation stack too large")
+	}
+	return e.LoadScript(contract.Script, rvcount)
+}
+
+// dynamicHash returns the hash of a dynamic call, after checking the
+// executing contract was deployed with dynamic invoke.
+func (e *Engine) dynamicHash(ctx *Context) utils.Uint160 {
+	contract, err := e.Contracts.GetContract(ctx.ScriptHash)
+	if err != nil {
+		panic(err)
+	}
+	if contract == nil || !contract.HasDynamicInvoke {
+		panic("runtime error: dynamic invoke not allowed")
+	}
+	hash, err := utils.Uint160DecodeBytes(utils.BytesReverse(e.PopBytes()))
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) executeOp(ctx *Context, op byte, operand []byte, start int) {
+	if op <= opcode.PUSHDATA4 {
+		e.pushBytes(operand)
+		return
+	}
+	if op == opcode.PUSHM1 || (op >= opcode.PUSH1 && op <= opcode.PUSH16) {
+		e.Push(NewInteger(big.NewInt(int64(op) - int64(opcode.PUSH1) + 1)))
+		return
+	}
+
+	stack := ctx.Evaluation
+	switch op {
+	case opcode.NOP:
+
+	// Flow control
+	case opcode.JMP, opcode.JMPIF, opcode.JMPIFNOT:
+		target := e.jumpTarget(ctx, start, operand)
+		jump := true
+		if op != opcode.JMP {
+			jump = e.PopBoolean()
+			if op == opcode.JMPIFNOT {
+				jump = !jump
+			}
+		}
+		if jump {
+			ctx.IP = target
+		}
+	case opcode.CALL:
+		target := e.jumpTarget(ctx, start, operand)
+		if len(e.invocation) >= MaxInvocationStackSize {
+			panic("runtime error: invocation stack too large")
+		}
+		call := e.LoadScript(ctx.Script, -1)
+		stack.CopyTo(call.Evaluation, -1)
+		stack.Clear()
+		call.IP = target
+	case opcode.RET:
+		e.invocation = e.invocation[:len(e.invocation)-1]
+		rvcount := ctx.RVCount
+		if rvcount == -1 {
+			rvcount = stack.Count()
+		}
+		if rvcount > 0 {
+			if stack.Count() < rvcount {
+				panic("runtime error: not enough return values")
+			}
+			dst := &e.results
+			if caller := e.CurrentContext(); caller != nil {
+				dst = caller.Evaluation
+			}
+			stack.CopyTo(dst, rvcount)
+		}
+		if ctx.RVCount == -1 {
+			if caller := e.CurrentContext(); caller != nil {
+				ctx.Alt.CopyTo(caller.Alt, -1)
+			}
+		}
+	case opcode.APPCALL, opcode.TAILCALL:
+		var hash utils.Uint160
+		if isZero(operand) {
+			hash = e.dynamicHash(ctx)
+		} else {
+			hash, _ = utils.Uint160DecodeBytes(utils.BytesReverse(operand))
+		}
+		callee := e.loadContract(hash, -1)
+		stack.CopyTo(callee.Evaluation, -1)
+		if op == opcode.TAILCALL {
+			e.removeContext(ctx)
+		} else {
+			stack.Clear()
+		}
+	case opcode.SYSCALL:
+		api := string(operand)
+		fn := e.services[api]
+		if fn == nil {
+			panic(fmt.Errorf("runtime error: unknown syscall %s", api))
+		}
+		if err := fn(e); err != nil {
+			panic(err)
+		}
+	case opcode.CALL_I:
+		rvcount, pcount := int(operand[0]), int(operand[1])
+		if stack.Count() < pcount {
+			panic("runtime error: not enough parameters")
+		}
+		target := e.jumpTarget(ctx, start+2, operand[2:])
+		if len(e.invocation) >= MaxInvocationStackSize {
+			panic("runtime error: invocation stack too large")
+		}
+		call := e.LoadScript(ctx.Script, rvcount)
+		e.moveParams(stack, call.Evaluation, pcount)
+		call.IP = target
+	case opcode.CALL_E, opcode.CALL_ED, opcode.CALL_ET, opcode.CALL_EDT:
+		rvcount, pcount := int(operand[0]), int(operand[1])
+		if stack.Count() < pcount {
+			panic("runtime error: not enough parameters")
+		}
+		// A tail call returns to the caller of ctx, which expects its count.
+		if (op == opcode.CALL_ET || op == opcode.CALL_EDT) && ctx.RVCount != rvcount {
+			panic("runtime error: return value count mismatch")
+		}
+		var hash utils.Uint160
+		if op == opcode.CALL_ED || op == opcode.CALL_EDT {
+			hash = e.dynamicHash(ctx)
+		} else {
+			hash, _ = utils.Uint160DecodeBytes(utils.BytesReverse(operand[2:]))
+		}
+		callee := e.loadContract(hash, rvcount)
+		e.moveParams(stack, callee.Evaluation, pcount)
+		if op == opcode.CALL_ET || op == opcode.CALL_EDT {
+			e.removeContext(ctx)
+		}
+
+	// Stack
+	case opcode.DUPFROMALTSTACK:
+		e.Push(ctx.Alt.Peek(0))
+	case opcode.TOALTSTACK:
+		ctx.Alt.Push(e.Pop())
+	case opcode.FROMALTSTACK:
+		e.Push(ctx.Alt.Pop())
+	case opcode.XDROP:
+		n := e.popInt()
+		if n < 0 {
+			panic("runtime error: negative index")
+		}
+		stack.Remove(n)
+	case opcode.XSWAP:
+		n := e.popInt()
+		if n < 0 {
+			panic("runtime error: negative index")
+		}
+		if n > 0 {
+			item := stack.Peek(n)
+			stack.Set(n, stack.Peek(0))
+			stack.Set(0, item)
+		}
+	case opcode.XTUCK:
+		n := e.popInt()
+		if n <= 0 {
+			panic("runtime error: index must be positive")
+		}
+		stack.Insert(n, stack.Peek(0))
+	case opcode.DEPTH:
+		e.Push(NewInteger(big.NewInt(int64(stack.Count()))))
+	case opcode.DROP:
+		e.Pop()
+	case opcode.DUP:
+		e.Push(stack.Peek(0))
+	case opcode.NIP:
+		stack.Remove(1)
+	case opcode.OVER:
+		e.Push(stack.Peek(1))
+	case opcode.PICK:
+		n := e.popInt()
+		if n < 0 {
+			panic("runtime error: negative index")
+		}
+		e.Push(stack.Peek(n))
+	case opcode.ROLL:
+		n := e.popInt()
+		if n < 0 {
+			panic("runtime error: negative index")
+		}
+		if n > 0 {
+			e.Push(stack.Remove(n))
+		}
+	case opcode.ROT:
+		e.Push(stack.Remove(2))
+	case opcode.SWAP:
+		e.Push(stack.Remove(1))
+	case opcode.TUCK:
+		stack.Insert(2, stack.Peek(0))
+
+	// Splice
+	case opcode.CAT:
+		x2 := e.PopBytes()
+		x1 := e.PopBytes()
+		data := make([]byte, 0, len(x1)+len(x2))
+		e.pushBytes(append(append(data, x1...), x2...))
+	case opcode.SUBSTR:
+		count := e.popInt()
+		if count < 0 {
+			panic("runtime error: negative count")
+		}
+		index := e.popInt()
+		if index < 0 {
+			panic("runtime error: negative index")
+		}
+		x := e.PopBytes()
+		if index > len(x) {
+			e.pushBytes([]byte{})
+			break
+		}
+		if index+count > len(x) {
+			count = len(x) - index
+		}
+		e.pushBytes(append([]byte{}, x[index:index+count]...))
+	case opcode.LEFT:
+		count := e.popInt()
+		if count < 0 {
+			panic("runtime error: negative count")
+		}
+		x := e.PopBytes()
+		if count < len(x) {
+			x = x[:count]
+		}
+		e.pushBytes(append([]byte{}, x...))
+	case opcode.RIGHT:
+		count := e.popInt()
+		if count < 0 {
+			panic("runtime error: negative count")
+		}
+		x := e.PopBytes()
+		if count > len(x) {
+			panic("runtime error: count out of range")
+		}
+		e.pushBytes(append([]byte{}, x[len(x)-count:]...))
+	case opcode.SIZE:
+		e.Push(NewInteger(big.NewInt(int64(len(e.PopBytes())))))
+
+	// Bitwise logic
+	case opcode.INVERT:
+		e.pushInt(new(big.Int).Not(e.PopBigInteger()))
+	case opcode.AND, opcode.OR, opcode.XOR:
+		x2 := e.PopBigInteger()
+		x1 := e.PopBigInteger()
+		r := new(big.Int)
+		switch op {
+		case opcode.AND:
+			r.And(x1, x2)
+		case opcode.OR:
+			r.Or(x1, x2)
+		default:
+			r.Xor(x1, x2)
+		}
+		e.pushInt(r)
+	case opcode.EQUAL:
+		x2 := e.Pop()
+		x1 := e.Pop()
+		e.Push(NewBoolean(x2.Equals(x1)))
+
+	// Arithmetic
+	case opcode.INC:
+		e.pushInt(new(big.Int).Add(e.PopBigInteger(), big.NewInt(1)))
+	case opcode.DEC:
+		e.pushInt(new(big.Int).Sub(e.PopBigInteger(), big.NewInt(1)))
+	case opcode.SIGN:
+		e.pushInt(big.NewInt(int64(e.PopBigInteger().Sign())))
+	case opcode.NEGATE:
+		e.pushInt(new(big.Int).Neg(e.PopBigInteger()))
+	case opcode.ABS:
+		e.pushInt(new(big.Int).Abs(e.PopBigInteger()))
+	case opcode.NOT:
+		e.Push(NewBoolean(!e.PopBoolean()))
+	case opcode.NZ:
+		e.Push(NewBoolean(e.PopBigInteger().Sign() != 0))
+	case opcode.ADD, opcode.SUB, opcode.MUL, opcode.DIV, opcode.MOD, opcode.MIN, opcode.MAX:
+		x2 := e.PopBigInteger()
+		x1 := e.PopBigInteger()
+		r := new(big.Int)
+		switch op {
+		case opcode.ADD:
+			r.Add(x1, x2)
+		case opcode.SUB:
+			r.Sub(x1, x2)
+		case opcode.MUL:
+			r.Mul(x1, x2)
+		case opcode.DIV, opcode.MOD:
+			if x2.Sign() == 0 {
+				panic("runtime error: division by zero")
+			}
+			// BigInteger division truncates toward zero, as Quo and Rem do.
+			if op == opcode.DIV {
+				r.Quo(x1, x2)
+			} else {
+				r.Rem(x1, x2)
+			}
+		case opcode.MIN:
+			r.Set(x1)
+			if x2.Cmp(x1) < 0 {
+				r.Set(x2)
+			}
+		case opcode.MAX:
+			r.Set(x1)
+			if x2.Cmp(x1) > 0 {
+				r.Set(x2)
+			}
+		}
+		e.pushInt(r)
+	case opcode.SHL, opcode.SHR:
+		shift := e.popInt()
+		if shift < MinShift || shift > MaxShift {
+			panic("runtime error: shift out of range")
+		}
+		if shift == 0 {
+			break
+		}
+		x := e.PopBigInteger()
+		// A negative shift goes the other way.
+		if op == opcode.SHR {
+			shift = -shift
+		}
+		if shift > 0 {
+			e.pushInt(new(big.Int).Lsh(x, uint(shift)))
+		} else {
+			e.pushInt(new(big.Int).Rsh(x, uint(-shift)))
+		}
+	case opcode.BOOLAND, opcode.BOOLOR:
+		x2 := e.PopBoolean()
+		x1 := e.PopBoolean()
+		if op == opcode.BOOLAND {
+			e.Push(NewBoolean(x1 && x2))
+		} else {
+			e.Push(NewBoolean(x1 || x2))
+		}
+	case opcode.NUMEQUAL, opcode.NUMNOTEQUAL, opcode.LT, opcode.GT, opcode.LTE, opcode.GTE:
+		x2 := e.PopBigInteger()
+		x1 := e.PopBigInteger()
+		c := x1.Cmp(x2)
+		var r bool
+		switch op {
+		case opcode.NUMEQUAL:
+			r = c == 0
+		case opcode.NUMNOTEQUAL:
+			r = c != 0
+		case opcode.LT:
+			r = c < 0
+		case opcode.GT:
+			r = c > 0
+		case opcode.LTE:
+			r = c <= 0
+		case opcode.GTE:
+			r = c >= 0
+		}
+		e.Push(NewBoolean(r))
+	case opcode.WITHIN:
+		b := e.PopBigInteger()
+		a := e.PopBigInteger()
+		x := e.PopBigInteger()
+		e.Push(NewBoolean(a.Cmp(x) <= 0 && x.Cmp(b) < 0))
+
+	// Crypto
+	case opcode.SHA1:
+		h := sha1.Sum(e.PopBytes())
+		e.pushBytes(h[:])
+	case opcode.SHA256:
+		h := sha256.Sum256(e.PopBytes())
+		e.pushBytes(h[:])
+	case opcode.HASH160:
+		e.pushBytes(hash160(e.PopBytes()))
+	case opcode.HASH256:
+		h := sha256.Sum256(e.PopBytes())
+		h = sha256.Sum256(h[:])
+		e.pushBytes(h[:])
+	case opcode.CHECKSIG:
+		pubkey := e.PopBytes()
+		signature := e.PopBytes()
+		e.Push(NewBoolean(VerifySignature(e.message(), signature, pubkey)))
+	case opcode.VERIFY:
+		pubkey := e.PopBytes()
+		signature := e.PopBytes()
+		message := e.PopBytes()
+		e.Push(NewBoolean(VerifySignature(message, signature, pubkey)))
+	case opcode.CHECKMULTISIG:
+		pubkeys := e.popList()
+		if len(pubkeys) == 0 {
+			panic("runtime error: no public keys")
+		}
+		signatures := e.popList()
+		m, n := len(signatures), len(pubkeys)
+		if m == 0 || m > n {
+			panic("runtime error: invalid signature count")
+		}
+		message := e.message()
+		ok := true
+		for i, j := 0, 0; ok && i < m && j < n; {
+			if VerifySignature(message, signatures[i], pubkeys[j]) {
+				i++
+			}
+			j++
+			if m-i > n-j {
+				ok = false
+			}
+		}
+		e.Push(NewBoolean(ok))
+
+	// Array
+	case opcode.ARRAYSIZE:
+		item := e.Pop()
+		switch v := item.(type) {
+		case *Array:
+			e.Push(NewInteger(big.NewInt(int64(len(v.Items)))))
+		case *Struct:
+			e.Push(NewInteger(big.NewInt(int64(len(v.Items)))))
+		case *Map:
+			e.Push(NewInteger(big.NewInt(int64(len(v.Keys)))))
+		default:
+			e.Push(NewInteger(big.NewInt(int64(len(item.GetByteArray())))))
+		}
+	case opcode.PACK:
+		size := e.popInt()
+		if size < 0 || size > stack.Count() || size > MaxArraySize {
+			panic("runtime error: invalid pack size")
+		}
+		items := make([]StackItem, size)
+		for i := range items {
+			items[i] = e.Pop()
+		}
+		e.Push(NewArray(items))
+	case opcode.UNPACK:
+		arr, ok := arrayItems(e.Pop())
+		if !ok {
+			panic("runtime error: unpack of non array")
+		}
+		for i := len(arr.Items) - 1; i >= 0; i-- {
+			e.Push(arr.Items[i])
+		}
+		e.Push(NewInteger(big.NewInt(int64(len(arr.Items)))))
+	case opcode.PICKITEM:
+		key := e.popKey()
+		switch v := e.Pop().(type) {
+		case *Array, *Struct:
+			arr, _ := arrayItems(v)
+			e.Push(arr.Items[e.arrayIndex(key, len(arr.Items))])
+		case *Map:
+			value, ok := v.Get(key)
+			if !ok {
+				panic("runtime error: key not found")
+			}
+			e.Push(value)
+		default:
+			panic("runtime error: pickitem of non collection")
+		}
+	case opcode.SETITEM:
+		value := e.Pop()
+		if s, ok := value.(*Struct); ok {
+			value = s.Clone()
+		}
+		key := e.popKey()
+		switch v := e.Pop().(type) {
+		case *Array, *Struct:
+			arr, _ := arrayItems(v)
+			arr.Items[e.arrayIndex(key, len(arr.Items))] = value
+		case *Map:
+			if _, ok := v.Get(key); !ok && len(v.Keys) >= MaxArraySize {
+				panic("runtime error: map too large")
+			}
+			v.Set(key, value)
+		default:
+			panic("runtime error: setitem of non collection")
+		}
+	case opcode.NEWARRAY, opcode.NEWSTRUCT:
+		item := e.Pop()
+		// An array of the same kind is kept as is; one of the other kind is
+		// converted to a shallow copy.
+		switch v := item.(type) {
+		case *Array:
+			if op == opcode.NEWARRAY {
+				e.Push(v)
+			} else {
+				e.Push(NewStruct(append([]StackItem(nil), v.Items...)))
+			}
+		case *Struct:
+			if op == opcode.NEWSTRUCT {
+				e.Push(v)
+			} else {
+				e.Push(NewArray(append([]StackItem(nil), v.Items...)))
+			}
+		default:
+			count := item.GetBigInteger()
+			if count.Sign() < 0 || count.Cmp(big.NewInt(MaxArraySize)) > 0 {
+				panic("runtime error: invalid array size")
+			}
+			items := make([]StackItem, count.Int64())
+			for i := range items {
+				items[i] = NewBoolean(false)
+			}
+			if op == opcode.NEWARRAY {
+				e.Push(NewArray(items))
+			} else {
+				e.Push(NewStruct(items))
+			}
+		}
+	case opcode.NEWMAP:
+		e.Push(NewMap())
+	case opcode.APPEND:
+		item := e.Pop()
+		if s, ok := item.(*Struct); ok {
+			item = s.Clone()
+		}
+		arr, ok := arrayItems(e.Pop())
+		if !ok {
+			panic("runtime error: append to non array")
+		}
+		if len(arr.Items) >= MaxArraySize {
+			panic("runtime error: array too large")
+		}
+		arr.Items = append(arr.Items, item)
+	case opcode.REVERSE:
+		arr, ok := arrayItems(e.Pop())
+		if !ok {
+			panic("runtime error: reverse of non array")
+		}
+		for i, j := 0, len(arr.Items)-1; i < j; i, j = i+1, j-1 {
+			arr.Items[i], arr.Items[j] = arr.Items[j], arr.Items[i]
+		}
+	case opcode.REMOVE:
+		key := e.popKey()
+		switch v := e.Pop().(type) {
+		case *Array, *Struct:
+			arr, _ := arrayItems(v)
+			i := e.arrayIndex(key, len(arr.Items))
+			arr.Items = append(arr.Items[:i], arr.Items[i+1:]...)
+		case *Map:
+			v.Remove(key)
+		default:
+			panic("runtime error: remove from non collection")
+		}
+	case opcode.HASKEY:
+		key := e.popKey()
+		switch v := e.Pop().(type) {
+		case *Array, *Struct:
+			arr, _ := arrayItems(v)
+			index := key.GetBigInteger()
+			if index.Sign() < 0 {
+				panic("runtime error: negative index")
+			}
+			e.Push(NewBoolean(index.Cmp(big.NewInt(int64(len(arr.Items)))) < 0))
+		case *Map:
+			_, ok := v.Get(key)
+			e.Push(NewBoolean(ok))
+		default:
+			panic("runtime error: haskey of non collection")
+		}
+	case opcode.KEYS:
+		m, ok := e.Pop().(*Map)
+		if !ok {
+			panic("runtime error: keys of non map")
+		}
+		e.Push(NewArray(append([]StackItem{}, m.Keys...)))
+	case opcode.VALUES:
+		var values []StackItem
+		switch v := e.Pop().(type) {
+		case *Array, *Struct:
+			arr, _ := arrayItems(v)
+			values = arr.Items
+		case *Map:
+			values = v.Values
+		default:
+			panic("runtime error: values of non collection")
+		}
+		items := make([]StackItem, len(values))
+		for i, value := range values {
+			if s, ok := value.(*Struct); ok {
+				value = s.Clone()
+			}
+			items[i] = value
+		}
+		e.Push(NewArray(items))
+
+	// Exceptions
+	case opcode.THROW:
+		panic("runtime error: THROW")
+	case opcode.THROWIFNOT:
+		if !e.PopBoolean() {
+			panic("runtime error: THROWIFNOT")
+		}
+
+	default:
+		panic(fmt.Errorf("runtime error: opcode %s not supported", opcode.Name(op)))
+	}
+}
+
+func (e *Engine) removeContext(ctx *Context) {
+	for i := len(e.invocation) - 1; i >= 0; i-- {
+		if e.invocation[i] == ctx {
+			e.invocation = append(e.invocation[:i], e.invocation[i+1:]...)
+			return
+		}
+	}
+}
+
+func (e *Engine) moveParams(src, dst *Stack, count int) {
+	src.CopyTo(dst, count)
+	for i := 0; i < count; i++ {
+		src.Pop()
+	}
+}
+
+func (e *Engine) popKey() StackItem {
+	key := e.Pop()
+	if isCollection(key) {
+		panic("runtime error: collection used as key")
+	}
+	return key
+}
+
+func (e *Engine) arrayIndex(key StackItem, length int) int {
+	index := key.GetBigInteger()
+	if index.Sign() < 0 || index.Cmp(big.NewInt(int64(length))) >= 0 {
+		panic("runtime error: array index out of range")
+	}
+	return int(index.Int64())
+}
+
+// popList pops either an array of byte arrays, or a count followed by that
+// many byte arrays, as CHECKMULTISIG takes its keys and signatures.
+func (e *Engine) popList() [][]byte {
+	item := e.Pop()
+	if arr, ok := arrayItems(item); ok {
+		list := make([][]byte, len(arr.Items))
+		for i, it := range arr.Items {
+			list[i] = it.GetByteArray()
+		}
+		return list
+	}
+	n := item.GetBigInteger()
+	if n.Sign() < 1 || n.Cmp(big.NewInt(int64(e.CurrentContext().Evaluation.Count()))) > 0 {
+		panic("runtime error: invalid list size")
+	}
+	list := make([][]byte, n.Int64())
+	for i := range list {
+		list[i] = e.PopBytes()
+	}
+	return list
+}
+
+func (e *Engine) message() []byte {
+	if e.Container == nil {
+		return nil
+	}
+	msg, _ := e.Container.GetMessage()
+	return msg
+}
+
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	ripemd := ripemd160.New()
+	ripemd.Write(sha[:])
+	return ripemd.Sum(nil)
+}
+
+// VerifySignature checks a 64 byte r||s ECDSA P-256 signature of the SHA-256
+// digest of message. pubkey is in compressed or uncompressed form.
+func VerifySignature(message, signature, pubkey []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+	var x, y *big.Int
+	switch len(pubkey) {
+	case 33:
+		x, y = elliptic.UnmarshalCompressed(elliptic.P256(), pubkey)
+	case 65:
+		x, y = elliptic.Unmarshal(elliptic.P256(), pubkey)
+	}
+	if x == nil {
+		return false
+	}
+	digest := sha256.Sum256(message)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	return ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, digest[:], r, s)
+}
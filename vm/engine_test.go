@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+)
+
+type message []byte
+
+func (m message) GetMessage() ([]byte, bool) {
+	return m, true
+}
+
+func syscall(api string) []byte {
+	return append([]byte{opcode.SYSCALL, byte(len(api))}, api...)
+}
+
+func TestArithmetic(t *testing.T) {
+	// (3 + 5) * 2 - 1, then a map round trip through Runtime.Serialize
+	script := []byte{opcode.PUSH3, opcode.PUSH5, opcode.ADD, opcode.PUSH2, opcode.MUL, opcode.DEC}
+	script = append(script, opcode.NEWMAP, opcode.DUP, opcode.PUSH1, 0x01, 'a', opcode.SETITEM)
+	script = append(script, syscall("Neo.Runtime.Serialize")...)
+	script = append(script, syscall("Neo.Runtime.Deserialize")...)
+	script = append(script, opcode.PUSH1, opcode.PICKITEM)
+
+	result := NewEngine(Application, nil).Run(script)
+	if result.State != HALT {
+		t.Fatalf("state %s: %v", result.State, result.Err)
+	}
+	if len(result.Stack) != 2 {
+		t.Fatalf("result stack size %d", len(result.Stack))
+	}
+	if string(result.Stack[0].GetByteArray()) != "a" || result.Stack[1].GetBigInteger().Int64() != 15 {
+		t.Fatalf("unexpected result %s, %s", ItemString(result.Stack[0]), ItemString(result.Stack[1]))
+	}
+	// pushes are free and the other nine instructions cost 1
+	if result.GasConsumed != 9*GasRatio {
+		t.Fatalf("gas consumed %d", result.GasConsumed)
+	}
+}
+
+func TestAppCallStorage(t *testing.T) {
+	// contract: put(arg0, "v"); return get(arg0)
+	contract := []byte{opcode.DUP, 0x01, 'v', opcode.SWAP}
+	contract = append(contract, syscall("Neo.Storage.GetContext")...)
+	contract = append(contract, syscall("Neo.Storage.Put")...)
+	contract = append(contract, syscall("Neo.Storage.GetContext")...)
+	contract = append(contract, syscall("Neo.Storage.Get")...)
+	contract = append(contract, opcode.RET)
+
+	e := NewEngine(Application, nil)
+	contracts := MemoryContracts{}
+	hash := contracts.Add(&Contract{Script: contract, HasStorage: true})
+	e.Contracts = contracts
+
+	script := append([]byte{0x01, 'k', opcode.APPCALL}, hash.BytesReverse()...)
+	result := e.Run(script)
+	if result.State != HALT {
+		t.Fatalf("state %s: %v", result.State, result.Err)
+	}
+	if !bytes.Equal(result.Stack[0].GetByteArray(), []byte("v")) {
+		t.Fatalf("unexpected result %s", ItemString(result.Stack[0]))
+	}
+	// APPCALL 10, DUP/SWAP/RET 3, two GetContext 2, Put 1000, Get 100
+	if result.GasConsumed != 1115*GasRatio {
+		t.Fatalf("gas consumed %d", result.GasConsumed)
+	}
+
+	e = NewEngine(Application, nil)
+	e.Contracts = MemoryContracts{hash: &Contract{Script: contract}}
+	if result := e.Run(script); result.State != FAULT {
+		t.Fatal("storage without HasStorage must fault")
+	}
+}
+
+func TestCheckSig(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := message("hello world")
+	digest := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	pubkey := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+
+	script := append([]byte{byte(len(signature))}, signature...)
+	script = append(script, byte(len(pubkey)))
+	script = append(script, pubkey...)
+	script = append(script, opcode.CHECKSIG)
+
+	result := NewEngine(Verification, msg).Run(script)
+	if result.State != HALT || !result.Stack[0].GetBoolean() {
+		t.Fatalf("signature check failed: %s %v", result.State, result.Err)
+	}
+	result = NewEngine(Verification, message("other")).Run(script)
+	if result.State != HALT || result.Stack[0].GetBoolean() {
+		t.Fatal("signature of another message accepted")
+	}
+}
+
+func TestGasLimit(t *testing.T) {
+	// an endless loop: JMP 0
+	e := NewEngine(Application, nil)
+	e.GasLimit = GasFree
+	result := e.Run([]byte{opcode.JMP, 0x00, 0x00})
+	if result.State != FAULT || result.GasConsumed <= GasFree {
+		t.Fatalf("state %s, gas consumed %d", result.State, result.GasConsumed)
+	}
+}
+
+func TestReferenceSemantics(t *testing.T) {
+	run := func(script []byte) *Result {
+		e := NewEngine(Application, nil)
+		contracts := MemoryContracts{}
+		hash := contracts.Add(&Contract{Script: []byte{opcode.PUSH7, opcode.RET}})
+		e.Contracts = contracts
+		return e.Run(bytes.Replace(script, []byte("hash"), hash.BytesReverse(), 1))
+	}
+	for _, c := range []struct {
+		name   string
+		script []byte
+		result int64
+	}{
+		// negative shifts go the other way
+		{"SHL -1", []byte{opcode.PUSH8, opcode.PUSHM1, opcode.SHL}, 4},
+		{"SHR -2", []byte{opcode.PUSH3, opcode.PUSH2, opcode.NEGATE, opcode.SHR}, 12},
+		// NEWARRAY keeps an array, so the append shows in the original
+		{"NEWARRAY of array", []byte{opcode.PUSH1, opcode.NEWARRAY, opcode.DUP, opcode.NEWARRAY, opcode.PUSH5, opcode.APPEND, opcode.ARRAYSIZE}, 2},
+		// and copies a struct
+		{"NEWARRAY of struct", []byte{opcode.PUSH1, opcode.NEWSTRUCT, opcode.DUP, opcode.NEWARRAY, opcode.PUSH5, opcode.APPEND, opcode.ARRAYSIZE}, 1},
+		{"NEWSTRUCT of struct", []byte{opcode.PUSH1, opcode.NEWSTRUCT, opcode.DUP, opcode.NEWSTRUCT, opcode.PUSH5, opcode.APPEND, opcode.ARRAYSIZE}, 2},
+		// CALL_I returning one value tail calls a contract returning one
+		{"CALL_ET", append([]byte{opcode.CALL_I, 1, 0, 4, 0, opcode.RET, opcode.CALL_ET, 1, 0}, "hash"...), 7},
+	} {
+		result := run(c.script)
+		if result.State != HALT || len(result.Stack) != 1 || result.Stack[0].GetBigInteger().Int64() != c.result {
+			t.Fatalf("%s: %s %v %v", c.name, result.State, result.Stack, result.Err)
+		}
+	}
+
+	for _, c := range []struct {
+		name   string
+		script []byte
+	}{
+		{"SHL 257", []byte{opcode.PUSH1, opcode.PUSHBYTES1 + 1, 0x01, 0x01, opcode.SHL}},
+		{"CALL_ET count mismatch", append([]byte{opcode.CALL_I, 1, 0, 4, 0, opcode.RET, opcode.CALL_ET, 0, 0}, "hash"...)},
+	} {
+		if result := run(c.script); result.State != FAULT {
+			t.Fatalf("%s: %s", c.name, result.State)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package vm
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+)
+
+// EstimateGas statically prices script, walking its instructions once in
+// order, and returns the GAS it consumes in Fixed8 units.
+//
+// Jumps are not followed and the code of called contracts is not priced, so
+// the estimate is exact for the straight-line scripts the SDK builds:
+// parameter pushes followed by an APPCALL or a SYSCALL. Syscall arguments are
+// taken from the constants pushed before the call; when they can not be
+// known, Contract.Create and Contract.Migrate are priced with storage and
+// dynamic invoke.
+func EstimateGas(script []byte) (int64, error) {
+	stack := &Stack{}
+	var price int64
+	for ip := 0; ip < len(script); {
+		op, operand, next, err := opcode.Decode(script, ip)
+		if err != nil {
+			return 0, err
+		}
+		ip = next
+
+		switch {
+		case op <= opcode.PUSHDATA4:
+			stack.Push(NewByteArray(operand))
+		case op == opcode.PUSHM1 || (op >= opcode.PUSH1 && op <= opcode.PUSH16):
+			stack.Push(NewInteger(big.NewInt(int64(op) - int64(opcode.PUSH1) + 1)))
+		case op == opcode.SYSCALL:
+			api := string(operand)
+			price += estimateSysCall(api, stack)
+			simulateSysCall(api, stack)
+		default:
+			price += estimateOp(op, operand, stack)
+			stack.Clear()
+		}
+	}
+	return price * GasRatio, nil
+}
+
+// SystemFee returns the gas an invocation transaction has to attach to
+// consume gas, both in Fixed8 units: what exceeds GasFree, rounded up to a
+// whole GAS as nodes require.
+func SystemFee(gas int64) int64 {
+	fee := gas - GasFree
+	if fee <= 0 {
+		return 0
+	}
+	const one = 100000000
+	return (fee + one - 1) / one * one
+}
+
+func estimateOp(op byte, operand []byte, stack *Stack) (price int64) {
+	defer func() {
+		if r := recover(); r != nil {
+			price = opcode.Price(op)
+		}
+	}()
+	return OpPrice(op, operand, stack)
+}
+
+func estimateSysCall(api string, stack *Stack) (price int64) {
+	unknown := int64(-1)
+	if strings.HasSuffix(api, ".Contract.Create") || strings.HasSuffix(api, ".Contract.Migrate") {
+		unknown = ContractPrice(HasStorage | HasDynamicInvoke)
+		if stack.Count() <= 3 {
+			return unknown
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			price = unknown
+			if price < 0 {
+				price = SysCallPrice(api, &Stack{})
+			}
+		}
+	}()
+	return SysCallPrice(api, stack)
+}
+
+// simulateSysCall applies the stack effect of the syscalls whose arguments
+// later calls depend on, and forgets the stack for the others.
+func simulateSysCall(api string, stack *Stack) {
+	switch {
+	case strings.HasSuffix(api, ".Storage.GetContext"), strings.HasSuffix(api, ".Storage.GetReadOnlyContext"):
+		stack.Push(NewInteropInterface(&StorageContext{}))
+	case strings.HasSuffix(api, ".Storage.Put"), strings.HasSuffix(api, ".Storage.Delete"):
+		n := 3
+		if strings.HasSuffix(api, ".Storage.Delete") {
+			n = 2
+		}
+		if stack.Count() < n {
+			stack.Clear()
+			return
+		}
+		for i := 0; i < n; i++ {
+			stack.Pop()
+		}
+	default:
+		stack.Clear()
+	}
+}
@@ -0,0 +1,45 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+)
+
+func TestEstimateGas(t *testing.T) {
+	// Neo.Contract.Create with a 3 byte script and storage + dynamic invoke
+	script := []byte{opcode.PUSH0, opcode.PUSH0, opcode.PUSH0, opcode.PUSH0, opcode.PUSH0}
+	script = append(script, opcode.PUSH3, opcode.PUSH5, 0x01, 0x07, 0x03, 0x51, 0x52, 0x93)
+	script = append(script, syscall("Neo.Contract.Create")...)
+	gas, err := EstimateGas(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas != 1000*100000000 {
+		t.Fatalf("contract create estimate %d", gas)
+	}
+	if fee := SystemFee(gas); fee != 990*100000000 {
+		t.Fatalf("contract create fee %d", fee)
+	}
+
+	// a 2000 byte value stored through Neo.Storage.Put costs 2 GAS
+	script = append([]byte{opcode.PUSHDATA2, 0xd0, 0x07}, bytes.Repeat([]byte{1}, 2000)...)
+	script = append(script, 0x01, 'k')
+	script = append(script, syscall("Neo.Storage.GetContext")...)
+	script = append(script, syscall("Neo.Storage.Put")...)
+	gas, err = EstimateGas(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas != 2001*GasRatio {
+		t.Fatalf("storage put estimate %d", gas)
+	}
+	if fee := SystemFee(gas); fee != 0 {
+		t.Fatalf("storage put fee %d", fee)
+	}
+
+	if fee := SystemFee(GasFree + 1); fee != 100000000 {
+		t.Fatalf("fee must round up to a whole GAS, got %d", fee)
+	}
+}
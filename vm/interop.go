@@ -0,0 +1,244 @@
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// StorageContext is the handle returned by Storage.GetContext.
+type StorageContext struct {
+	ScriptHash utils.Uint160
+	ReadOnly   bool
+}
+
+func registerDefaultServices(e *Engine) {
+	e.Register("System.Runtime.Platform", func(e *Engine) error {
+		e.Push(NewByteArray([]byte("NEO")))
+		return nil
+	})
+	registerAll(e, "Runtime.GetTrigger", runtimeGetTrigger)
+	registerAll(e, "Runtime.CheckWitness", runtimeCheckWitness)
+	registerAll(e, "Runtime.Notify", runtimeNotify)
+	registerAll(e, "Runtime.Log", runtimeLog)
+	registerAll(e, "Runtime.GetTime", runtimeGetTime)
+	registerAll(e, "Runtime.Serialize", runtimeSerialize)
+	registerAll(e, "Runtime.Deserialize", runtimeDeserialize)
+	registerAll(e, "Blockchain.GetHeight", blockchainGetHeight)
+
+	e.Register("System.ExecutionEngine.GetScriptContainer", func(e *Engine) error {
+		e.Push(NewInteropInterface(e.Container))
+		return nil
+	})
+	e.Register("System.ExecutionEngine.GetExecutingScriptHash", func(e *Engine) error {
+		e.Push(NewByteArray(e.CurrentContext().ScriptHash.BytesReverse()))
+		return nil
+	})
+	e.Register("System.ExecutionEngine.GetCallingScriptHash", func(e *Engine) error {
+		if ctx := e.CallingContext(); ctx != nil {
+			e.Push(NewByteArray(ctx.ScriptHash.BytesReverse()))
+		} else {
+			e.Push(NewByteArray([]byte{}))
+		}
+		return nil
+	})
+	e.Register("System.ExecutionEngine.GetEntryScriptHash", func(e *Engine) error {
+		e.Push(NewByteArray(e.EntryContext().ScriptHash.BytesReverse()))
+		return nil
+	})
+
+	registerAll(e, "Storage.GetContext", storageGetContext)
+	registerAll(e, "Storage.GetReadOnlyContext", storageGetReadOnlyContext)
+	registerAll(e, "Storage.Get", storageGet)
+	registerAll(e, "Storage.Put", storagePut)
+	registerAll(e, "Storage.PutEx", storagePutEx)
+	registerAll(e, "Storage.Delete", storageDelete)
+	registerAll(e, "StorageContext.AsReadOnly", storageContextAsReadOnly)
+}
+
+// registerAll registers fn under the System, Neo and AntShares namespaces.
+func registerAll(e *Engine, name string, fn InteropFunc) {
+	e.Register("System."+name, fn)
+	e.Register("Neo."+name, fn)
+	e.Register("AntShares."+name, fn)
+}
+
+func runtimeGetTrigger(e *Engine) error {
+	e.Push(NewInteger(big.NewInt(int64(e.Trigger))))
+	return nil
+}
+
+func runtimeCheckWitness(e *Engine) error {
+	data := e.PopBytes()
+	var hash utils.Uint160
+	switch len(data) {
+	case 20:
+		hash, _ = utils.Uint160DecodeBytes(utils.BytesReverse(data))
+	case 33:
+		script := append([]byte{byte(len(data))}, data...)
+		hash = ScriptHash(append(script, opcode.CHECKSIG))
+	default:
+		return errors.New("runtime error: invalid witness")
+	}
+	for _, w := range e.Witnesses {
+		if w.Equals(hash) {
+			e.Push(NewBoolean(true))
+			return nil
+		}
+	}
+	e.Push(NewBoolean(false))
+	return nil
+}
+
+func runtimeNotify(e *Engine) error {
+	e.notifications = append(e.notifications, Notification{
+		ScriptHash: e.CurrentContext().ScriptHash,
+		State:      e.Pop(),
+	})
+	return nil
+}
+
+func runtimeLog(e *Engine) error {
+	e.logs = append(e.logs, string(e.PopBytes()))
+	return nil
+}
+
+func runtimeGetTime(e *Engine) error {
+	e.Push(NewInteger(big.NewInt(int64(e.Time))))
+	return nil
+}
+
+func runtimeSerialize(e *Engine) error {
+	data, err := SerializeItem(e.Pop())
+	if err != nil {
+		return err
+	}
+	if len(data) > MaxItemSize {
+		return errors.New("runtime error: item too large")
+	}
+	e.Push(NewByteArray(data))
+	return nil
+}
+
+func runtimeDeserialize(e *Engine) error {
+	item, err := DeserializeItem(e.PopBytes())
+	if err != nil {
+		return err
+	}
+	e.Push(item)
+	return nil
+}
+
+func blockchainGetHeight(e *Engine) error {
+	e.Push(NewInteger(big.NewInt(int64(e.Height))))
+	return nil
+}
+
+func storageGetContext(e *Engine) error {
+	e.Push(NewInteropInterface(&StorageContext{ScriptHash: e.CurrentContext().ScriptHash}))
+	return nil
+}
+
+func storageGetReadOnlyContext(e *Engine) error {
+	e.Push(NewInteropInterface(&StorageContext{ScriptHash: e.CurrentContext().ScriptHash, ReadOnly: true}))
+	return nil
+}
+
+func storageContextAsReadOnly(e *Engine) error {
+	ctx, err := e.popStorageContext()
+	if err != nil {
+		return err
+	}
+	e.Push(NewInteropInterface(&StorageContext{ScriptHash: ctx.ScriptHash, ReadOnly: true}))
+	return nil
+}
+
+// popStorageContext pops a storage context and checks its contract may use storage.
+func (e *Engine) popStorageContext() (*StorageContext, error) {
+	item, ok := e.Pop().(*InteropInterface)
+	if !ok {
+		return nil, errors.New("runtime error: storage context expected")
+	}
+	ctx, ok := item.Value.(*StorageContext)
+	if !ok {
+		return nil, errors.New("runtime error: storage context expected")
+	}
+	if e.Contracts == nil || e.Storage == nil {
+		return nil, errors.New("runtime error: no storage provider")
+	}
+	contract, err := e.Contracts.GetContract(ctx.ScriptHash)
+	if err != nil {
+		return nil, err
+	}
+	if contract == nil || !contract.HasStorage {
+		return nil, errors.New("runtime error: contract has no storage")
+	}
+	return ctx, nil
+}
+
+func storageGet(e *Engine) error {
+	ctx, err := e.popStorageContext()
+	if err != nil {
+		return err
+	}
+	value, err := e.Storage.Get(ctx.ScriptHash, e.PopBytes())
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	e.Push(NewByteArray(value))
+	return nil
+}
+
+func (e *Engine) put(ctx *StorageContext, key, value []byte) error {
+	if e.Trigger != Application {
+		return errors.New("runtime error: storage is read only under verification")
+	}
+	if ctx.ReadOnly {
+		return errors.New("runtime error: storage context is read only")
+	}
+	if len(key) > MaxStorageKeySize {
+		return errors.New("runtime error: storage key too large")
+	}
+	return e.Storage.Put(ctx.ScriptHash, key, value)
+}
+
+func storagePut(e *Engine) error {
+	ctx, err := e.popStorageContext()
+	if err != nil {
+		return err
+	}
+	key := e.PopBytes()
+	value := e.PopBytes()
+	return e.put(ctx, key, value)
+}
+
+func storagePutEx(e *Engine) error {
+	ctx, err := e.popStorageContext()
+	if err != nil {
+		return err
+	}
+	key := e.PopBytes()
+	value := e.PopBytes()
+	// The storage flags only mark items as constant, which the emulator does not track.
+	e.PopBigInteger()
+	return e.put(ctx, key, value)
+}
+
+func storageDelete(e *Engine) error {
+	if e.Trigger != Application {
+		return errors.New("runtime error: storage is read only under verification")
+	}
+	ctx, err := e.popStorageContext()
+	if err != nil {
+		return err
+	}
+	if ctx.ReadOnly {
+		return errors.New("runtime error: storage context is read only")
+	}
+	return e.Storage.Delete(ctx.ScriptHash, e.PopBytes())
+}
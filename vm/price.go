@@ -0,0 +1,128 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/hzxiao/neo-thinsdk-go/opcode"
+)
+
+// GasRatio converts opcode prices, in units of 0.001 GAS, into Fixed8 units.
+const GasRatio int64 = 100000
+
+// GasFree is the GAS, in Fixed8 units, every invocation may consume for free.
+const GasFree int64 = 10 * 100000000
+
+// Contract property flags, as passed to Contract.Create.
+const (
+	HasStorage       byte = 1 << 0
+	HasDynamicInvoke byte = 1 << 1
+	Payable          byte = 1 << 2
+)
+
+// OpPrice returns the price of op in units of 0.001 GAS. stack is the
+// evaluation stack before the instruction runs; it is only peeked for SYSCALL
+// and CHECKMULTISIG, whose price depends on their arguments.
+func OpPrice(op byte, operand []byte, stack *Stack) int64 {
+	switch op {
+	case opcode.SYSCALL:
+		return SysCallPrice(string(operand), stack)
+	case opcode.CHECKMULTISIG:
+		if stack.Count() == 0 {
+			return 1
+		}
+		var n int64
+		if arr, ok := arrayItems(stack.Peek(0)); ok {
+			n = int64(len(arr.Items))
+		} else {
+			v := stack.Peek(0).GetBigInteger()
+			if v.IsInt64() {
+				n = v.Int64()
+			}
+		}
+		if n < 1 {
+			return 1
+		}
+		return opcode.Price(op) * n
+	}
+	return opcode.Price(op)
+}
+
+// SysCallPrice returns the price of the interop service api in units of
+// 0.001 GAS, following the NEO 2.x application engine. Storage.Put peeks the
+// key and value sizes, and Contract.Create the contract properties.
+func SysCallPrice(api string, stack *Stack) int64 {
+	name := api
+	for _, prefix := range []string{"System.", "Neo.", "AntShares."} {
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+
+	switch name {
+	case "Runtime.CheckWitness":
+		return 200
+	case "Blockchain.GetHeader":
+		return 100
+	case "Blockchain.GetBlock":
+		return 200
+	case "Blockchain.GetTransaction", "Blockchain.GetTransactionHeight":
+		return 100
+	case "Blockchain.GetAccount":
+		return 100
+	case "Blockchain.GetValidators":
+		return 200
+	case "Blockchain.GetAsset", "Blockchain.GetContract":
+		return 100
+	case "Transaction.GetReferences", "Transaction.GetUnspentCoins", "Transaction.GetWitnesses":
+		return 200
+	case "Witness.GetInvocationScript", "Witness.GetVerificationScript":
+		return 100
+	case "Account.IsStandard":
+		return 100
+	case "Asset.Create":
+		return 5000 * 100000000 / GasRatio
+	case "Asset.Renew":
+		years := int64(1)
+		if stack.Count() > 1 {
+			years = int64(byte(stack.Peek(1).GetBigInteger().Int64()))
+		}
+		return years * 5000 * 100000000 / GasRatio
+	case "Contract.Create", "Contract.Migrate":
+		var properties byte
+		if stack.Count() > 3 {
+			properties = byte(stack.Peek(3).GetBigInteger().Int64())
+		}
+		return ContractPrice(properties)
+	case "Storage.Get":
+		return 100
+	case "Storage.Put", "Storage.PutEx":
+		size := 0
+		if stack.Count() > 2 {
+			size = len(stack.Peek(1).GetByteArray()) + len(stack.Peek(2).GetByteArray())
+		}
+		return StoragePrice(size)
+	case "Storage.Delete":
+		return 100
+	}
+	return 1
+}
+
+// StoragePrice returns the price of storing size bytes of key and value, in
+// units of 0.001 GAS: one GAS per started KiB.
+func StoragePrice(size int) int64 {
+	return int64((size-1)/1024+1) * 1000
+}
+
+// ContractPrice returns the price of deploying a contract with the given
+// property flags, in units of 0.001 GAS.
+func ContractPrice(properties byte) int64 {
+	fee := int64(100)
+	if properties&HasStorage != 0 {
+		fee += 400
+	}
+	if properties&HasDynamicInvoke != 0 {
+		fee += 500
+	}
+	return fee * 100000000 / GasRatio
+}
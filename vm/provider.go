@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// ScriptContainer is the verifiable object a script runs for, usually a
+// transaction. CHECKSIG and CHECKMULTISIG verify signatures against its message.
+type ScriptContainer interface {
+	GetMessage() ([]byte, bool)
+}
+
+// Contract is a deployed contract as seen by the engine.
+type Contract struct {
+	Script           []byte
+	HasStorage       bool
+	HasDynamicInvoke bool
+}
+
+// ContractProvider resolves contract scripts for APPCALL, TAILCALL and the
+// CALL_E family. It returns a nil contract if hash is unknown.
+type ContractProvider interface {
+	GetContract(hash utils.Uint160) (*Contract, error)
+}
+
+// StorageProvider holds the storage of contracts. Get returns nil for a missing key.
+type StorageProvider interface {
+	Get(hash utils.Uint160, key []byte) ([]byte, error)
+	Put(hash utils.Uint160, key, value []byte) error
+	Delete(hash utils.Uint160, key []byte) error
+}
+
+// ScriptHash returns the hash of script, in the same byte order as
+// utils.Uint160DecodeString uses for script hash strings.
+func ScriptHash(script []byte) utils.Uint160 {
+	u, _ := utils.Uint160DecodeBytes(utils.BytesReverse(hash160(script)))
+	return u
+}
+
+// MemoryContracts is a ContractProvider backed by a map.
+type MemoryContracts map[utils.Uint160]*Contract
+
+// GetContract implements ContractProvider.
+func (m MemoryContracts) GetContract(hash utils.Uint160) (*Contract, error) {
+	return m[hash], nil
+}
+
+// Add registers contract and returns its script hash.
+func (m MemoryContracts) Add(contract *Contract) utils.Uint160 {
+	hash := ScriptHash(contract.Script)
+	m[hash] = contract
+	return hash
+}
+
+// MemoryStorage is a StorageProvider backed by a map.
+type MemoryStorage map[utils.Uint160]map[string][]byte
+
+// Get implements StorageProvider.
+func (m MemoryStorage) Get(hash utils.Uint160, key []byte) ([]byte, error) {
+	return m[hash][string(key)], nil
+}
+
+// Put implements StorageProvider.
+func (m MemoryStorage) Put(hash utils.Uint160, key, value []byte) error {
+	if m[hash] == nil {
+		m[hash] = make(map[string][]byte)
+	}
+	m[hash][string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Delete implements StorageProvider.
+func (m MemoryStorage) Delete(hash utils.Uint160, key []byte) error {
+	delete(m[hash], string(key))
+	return nil
+}
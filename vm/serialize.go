@@ -0,0 +1,171 @@
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// Stack item type tags used by Runtime.Serialize.
+const (
+	ByteArrayType        byte = 0x00
+	BooleanType          byte = 0x01
+	IntegerType          byte = 0x02
+	InteropInterfaceType byte = 0x40
+	ArrayType            byte = 0x80
+	StructType           byte = 0x81
+	MapType              byte = 0x82
+)
+
+// SerializeItem encodes item the way Runtime.Serialize does.
+func SerializeItem(item StackItem) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := serializeItem(buf, item, map[StackItem]bool{}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func serializeItem(buf *bytes.Buffer, item StackItem, seen map[StackItem]bool) error {
+	switch v := item.(type) {
+	case *ByteArray:
+		buf.WriteByte(ByteArrayType)
+		writeVarBytes(buf, v.Value)
+	case *Boolean:
+		buf.WriteByte(BooleanType)
+		if v.Value {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case *Integer:
+		buf.WriteByte(IntegerType)
+		writeVarBytes(buf, v.GetByteArray())
+	case *Array, *Struct:
+		if seen[item] {
+			return errors.New("runtime error: circular reference")
+		}
+		seen[item] = true
+		arr, _ := arrayItems(v)
+		if _, ok := v.(*Struct); ok {
+			buf.WriteByte(StructType)
+		} else {
+			buf.WriteByte(ArrayType)
+		}
+		utils.WriteVarInt(buf, uint64(len(arr.Items)))
+		for _, it := range arr.Items {
+			if err := serializeItem(buf, it, seen); err != nil {
+				return err
+			}
+		}
+		delete(seen, item)
+	case *Map:
+		if seen[item] {
+			return errors.New("runtime error: circular reference")
+		}
+		seen[item] = true
+		buf.WriteByte(MapType)
+		utils.WriteVarInt(buf, uint64(len(v.Keys)))
+		for i := range v.Keys {
+			if err := serializeItem(buf, v.Keys[i], seen); err != nil {
+				return err
+			}
+			if err := serializeItem(buf, v.Values[i], seen); err != nil {
+				return err
+			}
+		}
+		delete(seen, item)
+	default:
+		return fmt.Errorf("runtime error: can not serialize %T", item)
+	}
+	return nil
+}
+
+// DeserializeItem decodes data produced by Runtime.Serialize.
+func DeserializeItem(data []byte) (StackItem, error) {
+	buf := bytes.NewBuffer(data)
+	item, err := deserializeItem(buf)
+	if err != nil {
+		return nil, err
+	}
+	if buf.Len() > 0 {
+		return nil, errors.New("runtime error: trailing serialized data")
+	}
+	return item, nil
+}
+
+func deserializeItem(buf *bytes.Buffer) (StackItem, error) {
+	t, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case ByteArrayType:
+		data, err := readVarBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+		return NewByteArray(data), nil
+	case BooleanType:
+		b, err := buf.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewBoolean(b != 0), nil
+	case IntegerType:
+		data, err := readVarBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+		return NewInteger(utils.BytesToBigInt(data)), nil
+	case ArrayType, StructType:
+		count := utils.ReadVarInt(buf, MaxArraySize)
+		if count > MaxArraySize {
+			return nil, errors.New("runtime error: array too large")
+		}
+		items := make([]StackItem, count)
+		for i := range items {
+			if items[i], err = deserializeItem(buf); err != nil {
+				return nil, err
+			}
+		}
+		if t == StructType {
+			return NewStruct(items), nil
+		}
+		return NewArray(items), nil
+	case MapType:
+		count := utils.ReadVarInt(buf, MaxArraySize)
+		if count > MaxArraySize {
+			return nil, errors.New("runtime error: map too large")
+		}
+		m := NewMap()
+		for i := uint64(0); i < count; i++ {
+			key, err := deserializeItem(buf)
+			if err != nil {
+				return nil, err
+			}
+			value, err := deserializeItem(buf)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, value)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("runtime error: unknown stack item type 0x%02x", t)
+}
+
+func writeVarBytes(buf *bytes.Buffer, data []byte) {
+	utils.WriteVarInt(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+func readVarBytes(buf *bytes.Buffer) ([]byte, error) {
+	length := utils.ReadVarInt(buf, MaxItemSize)
+	if length > uint64(buf.Len()) {
+		return nil, errors.New("runtime error: truncated serialized data")
+	}
+	return append([]byte{}, buf.Next(int(length))...), nil
+}
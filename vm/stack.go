@@ -0,0 +1,82 @@
+package vm
+
+// Stack is a random access stack. Index 0 is the top of the stack.
+type Stack struct {
+	items []StackItem
+}
+
+// Count returns the number of items on the stack.
+func (s *Stack) Count() int {
+	return len(s.items)
+}
+
+// Push puts item on top of the stack.
+func (s *Stack) Push(item StackItem) {
+	s.items = append(s.items, item)
+}
+
+// Pop removes and returns the top item.
+func (s *Stack) Pop() StackItem {
+	return s.Remove(0)
+}
+
+// Peek returns the item n positions below the top without removing it.
+func (s *Stack) Peek(n int) StackItem {
+	if n < 0 || n >= len(s.items) {
+		panic("runtime error: stack index out of range")
+	}
+	return s.items[len(s.items)-1-n]
+}
+
+// Set replaces the item n positions below the top.
+func (s *Stack) Set(n int, item StackItem) {
+	if n < 0 || n >= len(s.items) {
+		panic("runtime error: stack index out of range")
+	}
+	s.items[len(s.items)-1-n] = item
+}
+
+// Insert puts item at position n, counting from the top.
+func (s *Stack) Insert(n int, item StackItem) {
+	if n < 0 || n > len(s.items) {
+		panic("runtime error: stack index out of range")
+	}
+	i := len(s.items) - n
+	s.items = append(s.items, nil)
+	copy(s.items[i+1:], s.items[i:])
+	s.items[i] = item
+}
+
+// Remove removes and returns the item n positions below the top.
+func (s *Stack) Remove(n int) StackItem {
+	if n < 0 || n >= len(s.items) {
+		panic("runtime error: stack index out of range")
+	}
+	i := len(s.items) - 1 - n
+	item := s.items[i]
+	s.items = append(s.items[:i], s.items[i+1:]...)
+	return item
+}
+
+// CopyTo pushes the top count items onto dst, keeping their order. A negative
+// count copies the whole stack.
+func (s *Stack) CopyTo(dst *Stack, count int) {
+	if count < 0 || count > len(s.items) {
+		count = len(s.items)
+	}
+	dst.items = append(dst.items, s.items[len(s.items)-count:]...)
+}
+
+// Clear removes all items from the stack.
+func (s *Stack) Clear() {
+	s.items = nil
+}
+
+// Items returns the stack items, the top of the stack first.
+func (s *Stack) Items() []StackItem {
+	items := make([]StackItem, len(s.items))
+	for i := range s.items {
+		items[i] = s.items[len(s.items)-1-i]
+	}
+	return items
+}
@@ -0,0 +1,352 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+)
+
+// StackItem is a value on the NeoVM evaluation stack.
+type StackItem interface {
+	// GetByteArray returns the byte representation of the item. It panics for
+	// arrays, maps and interop interfaces, which faults the engine.
+	GetByteArray() []byte
+	GetBigInteger() *big.Int
+	GetBoolean() bool
+	Equals(other StackItem) bool
+}
+
+// ByteArray is a byte string stack item.
+type ByteArray struct {
+	Value []byte
+}
+
+// Integer is an arbitrary precision integer stack item.
+type Integer struct {
+	Value *big.Int
+}
+
+// Boolean is a boolean stack item.
+type Boolean struct {
+	Value bool
+}
+
+// Array is a reference type list of stack items.
+type Array struct {
+	Items []StackItem
+}
+
+// Struct is a value type list of stack items. It is copied when stored
+// into another collection and compared by its contents.
+type Struct struct {
+	Array
+}
+
+// Map is an ordered dictionary keyed by primitive stack items.
+type Map struct {
+	Keys   []StackItem
+	Values []StackItem
+}
+
+// InteropInterface wraps a host object exposed by an interop service.
+type InteropInterface struct {
+	Value interface{}
+}
+
+// NewByteArray returns a ByteArray stack item.
+func NewByteArray(data []byte) *ByteArray {
+	return &ByteArray{Value: data}
+}
+
+// NewInteger returns an Integer stack item.
+func NewInteger(v *big.Int) *Integer {
+	return &Integer{Value: v}
+}
+
+// NewBoolean returns a Boolean stack item.
+func NewBoolean(b bool) *Boolean {
+	return &Boolean{Value: b}
+}
+
+// NewArray returns an Array stack item holding items.
+func NewArray(items []StackItem) *Array {
+	return &Array{Items: items}
+}
+
+// NewStruct returns a Struct stack item holding items.
+func NewStruct(items []StackItem) *Struct {
+	return &Struct{Array{Items: items}}
+}
+
+// NewMap returns an empty Map stack item.
+func NewMap() *Map {
+	return &Map{}
+}
+
+// NewInteropInterface returns an InteropInterface stack item wrapping v.
+func NewInteropInterface(v interface{}) *InteropInterface {
+	return &InteropInterface{Value: v}
+}
+
+func (item *ByteArray) GetByteArray() []byte {
+	return item.Value
+}
+
+func (item *ByteArray) GetBigInteger() *big.Int {
+	return utils.BytesToBigInt(item.Value)
+}
+
+func (item *ByteArray) GetBoolean() bool {
+	if len(item.Value) > MaxSizeForBigInteger {
+		return true
+	}
+	for _, b := range item.Value {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (item *ByteArray) Equals(other StackItem) bool {
+	if StackItem(item) == other {
+		return true
+	}
+	if other == nil || isCollection(other) {
+		return false
+	}
+	return bytes.Equal(item.Value, other.GetByteArray())
+}
+
+func (item *Integer) GetByteArray() []byte {
+	return utils.BigIntToBytes(item.Value)
+}
+
+func (item *Integer) GetBigInteger() *big.Int {
+	return item.Value
+}
+
+func (item *Integer) GetBoolean() bool {
+	return item.Value.Sign() != 0
+}
+
+func (item *Integer) Equals(other StackItem) bool {
+	if StackItem(item) == other {
+		return true
+	}
+	if o, ok := other.(*Integer); ok {
+		return item.Value.Cmp(o.Value) == 0
+	}
+	if other == nil || isCollection(other) {
+		return false
+	}
+	return bytes.Equal(item.GetByteArray(), other.GetByteArray())
+}
+
+func (item *Boolean) GetByteArray() []byte {
+	if item.Value {
+		return []byte{1}
+	}
+	return []byte{}
+}
+
+func (item *Boolean) GetBigInteger() *big.Int {
+	if item.Value {
+		return big.NewInt(1)
+	}
+	return big.NewInt(0)
+}
+
+func (item *Boolean) GetBoolean() bool {
+	return item.Value
+}
+
+func (item *Boolean) Equals(other StackItem) bool {
+	if StackItem(item) == other {
+		return true
+	}
+	if o, ok := other.(*Boolean); ok {
+		return item.Value == o.Value
+	}
+	if other == nil || isCollection(other) {
+		return false
+	}
+	return bytes.Equal(item.GetByteArray(), other.GetByteArray())
+}
+
+func (item *Array) GetByteArray() []byte {
+	panic("runtime error: array can not be converted to byte array")
+}
+
+func (item *Array) GetBigInteger() *big.Int {
+	panic("runtime error: array can not be converted to integer")
+}
+
+func (item *Array) GetBoolean() bool {
+	return true
+}
+
+func (item *Array) Equals(other StackItem) bool {
+	o, ok := other.(*Array)
+	return ok && o == item
+}
+
+// Clone returns a deep copy of the struct; nested structs are copied too.
+func (item *Struct) Clone() *Struct {
+	items := make([]StackItem, len(item.Items))
+	for i, v := range item.Items {
+		if s, ok := v.(*Struct); ok {
+			items[i] = s.Clone()
+		} else {
+			items[i] = v
+		}
+	}
+	return NewStruct(items)
+}
+
+func (item *Struct) Equals(other StackItem) bool {
+	if StackItem(item) == other {
+		return true
+	}
+	o, ok := other.(*Struct)
+	if !ok || len(o.Items) != len(item.Items) {
+		return false
+	}
+	for i := range item.Items {
+		if !item.Items[i].Equals(o.Items[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (item *Map) GetByteArray() []byte {
+	panic("runtime error: map can not be converted to byte array")
+}
+
+func (item *Map) GetBigInteger() *big.Int {
+	panic("runtime error: map can not be converted to integer")
+}
+
+func (item *Map) GetBoolean() bool {
+	return true
+}
+
+func (item *Map) Equals(other StackItem) bool {
+	o, ok := other.(*Map)
+	return ok && o == item
+}
+
+func (item *Map) index(key StackItem) int {
+	for i, k := range item.Keys {
+		if k.Equals(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value stored under key.
+func (item *Map) Get(key StackItem) (StackItem, bool) {
+	i := item.index(key)
+	if i < 0 {
+		return nil, false
+	}
+	return item.Values[i], true
+}
+
+// Set stores value under key, replacing any previous value.
+func (item *Map) Set(key, value StackItem) {
+	if i := item.index(key); i >= 0 {
+		item.Values[i] = value
+		return
+	}
+	item.Keys = append(item.Keys, key)
+	item.Values = append(item.Values, value)
+}
+
+// Remove deletes key from the map.
+func (item *Map) Remove(key StackItem) {
+	i := item.index(key)
+	if i < 0 {
+		return
+	}
+	item.Keys = append(item.Keys[:i], item.Keys[i+1:]...)
+	item.Values = append(item.Values[:i], item.Values[i+1:]...)
+}
+
+func (item *InteropInterface) GetByteArray() []byte {
+	panic("runtime error: interop interface can not be converted to byte array")
+}
+
+func (item *InteropInterface) GetBigInteger() *big.Int {
+	panic("runtime error: interop interface can not be converted to integer")
+}
+
+func (item *InteropInterface) GetBoolean() bool {
+	return item.Value != nil
+}
+
+func (item *InteropInterface) Equals(other StackItem) bool {
+	o, ok := other.(*InteropInterface)
+	return ok && (o == item || o.Value == item.Value)
+}
+
+func isCollection(item StackItem) bool {
+	switch item.(type) {
+	case *Array, *Struct, *Map:
+		return true
+	}
+	return false
+}
+
+// arrayItems returns the items of an Array or Struct.
+func arrayItems(item StackItem) (*Array, bool) {
+	switch v := item.(type) {
+	case *Array:
+		return v, true
+	case *Struct:
+		return &v.Array, true
+	}
+	return nil, false
+}
+
+// ItemString returns a readable representation of item, for logs and test failures.
+func ItemString(item StackItem) string {
+	switch v := item.(type) {
+	case *ByteArray:
+		return "0x" + utils.ToHexString(v.Value)
+	case *Integer:
+		return v.Value.String()
+	case *Boolean:
+		return fmt.Sprint(v.Value)
+	case *Array, *Struct:
+		arr, _ := arrayItems(v)
+		buf := &bytes.Buffer{}
+		buf.WriteByte('[')
+		for i, it := range arr.Items {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(ItemString(it))
+		}
+		buf.WriteByte(']')
+		return buf.String()
+	case *Map:
+		buf := &bytes.Buffer{}
+		buf.WriteByte('{')
+		for i := range v.Keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(ItemString(v.Keys[i]) + ": " + ItemString(v.Values[i]))
+		}
+		buf.WriteByte('}')
+		return buf.String()
+	case *InteropInterface:
+		return fmt.Sprintf("interop(%T)", v.Value)
+	}
+	return "<nil>"
+}
@@ -0,0 +1,276 @@
+// Package watcher detects deposits to watched addresses and reports them to
+// a webhook.
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neo-thinsdk-go/blockstream"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the body of a webhook
+// call, keyed with the secret of the watcher.
+const SignatureHeader = "X-Neo-Signature"
+
+// Kinds of deposits.
+const (
+	// UTXO deposits are outputs of NEO, GAS or another global asset.
+	UTXO = "utxo"
+	// Nep5 deposits are NEP-5 transfer notifications.
+	Nep5 = "nep5"
+)
+
+// Deposit is an incoming transfer to a watched address.
+type Deposit struct {
+	// Id identifies the deposit across deliveries, so that receivers can
+	// drop the duplicates at-least-once delivery implies.
+	Id      string `json:"id"`
+	Kind    string `json:"kind"`
+	TxId    string `json:"txid"`
+	Height  uint32 `json:"height"`
+	Address string `json:"address"`
+	// Asset is the asset id of a UTXO deposit or the contract hash of a
+	// NEP-5 one.
+	Asset string `json:"asset"`
+	// Amount is an integer in the smallest unit of the asset: 10^-8 for
+	// global assets, 10^-decimals for NEP-5 tokens.
+	Amount string `json:"amount"`
+}
+
+// Watcher scans confirmed blocks for deposits to its addresses and posts
+// each one to Webhook. A block is scanned once Confirmations blocks,
+// itself included, are on top of the chain.
+type Watcher struct {
+	Client  *rpc.Client
+	Webhook string
+	// Secret keys the signature of the webhook calls.
+	Secret        []byte
+	Confirmations uint32
+	// Retries is the number of times a failed webhook call is retried,
+	// waiting RetryDelay, doubled on every attempt, in between.
+	Retries    int
+	RetryDelay time.Duration
+	// HTTPClient sends the webhook calls; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// PollInterval, when set, overrides how often Run polls for new blocks.
+	PollInterval time.Duration
+
+	mu      sync.RWMutex
+	watched map[string]string
+}
+
+// New returns a watcher of the chain of client posting to webhook.
+func New(client *rpc.Client, webhook string, secret []byte) *Watcher {
+	return &Watcher{
+		Client:        client,
+		Webhook:       webhook,
+		Secret:        secret,
+		Confirmations: 1,
+		Retries:       5,
+		RetryDelay:    time.Second,
+		watched:       make(map[string]string),
+	}
+}
+
+// Watch adds addresses to the watched set.
+func (w *Watcher) Watch(addresses ...string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, address := range addresses {
+		hash, ok := neo.GetPublicKeyHashFromAddress(address)
+		if !ok {
+			return fmt.Errorf("invalid address %s", address)
+		}
+		w.watched[string(hash)] = address
+	}
+	return nil
+}
+
+// watchedAddress returns the address of the script hash, in the byte order
+// of GetPublicKeyHashFromAddress, when it is watched.
+func (w *Watcher) watchedAddress(hash []byte) (string, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	address, ok := w.watched[string(hash)]
+	return address, ok
+}
+
+// Scan returns the deposits to watched addresses in block and its NEP-5
+// transfers. Change returned to a watched address counts as a deposit too.
+func (w *Watcher) Scan(block *neo.Block, transfers []rpc.Nep5TransferEvent) []Deposit {
+	var deposits []Deposit
+	for _, tx := range block.Transactions {
+		txid := tx.GetTxId().String()
+		for i, output := range tx.GetOutputs() {
+			address, ok := w.watchedAddress(output.GetScriptHash().BytesReverse())
+			if !ok {
+				continue
+			}
+			deposits = append(deposits, Deposit{
+				Id:      fmt.Sprintf("%s:%d", txid, i),
+				Kind:    UTXO,
+				TxId:    txid,
+				Height:  block.Index,
+				Address: address,
+				Asset:   "0x" + output.GetAssetId().String(),
+				Amount:  fmt.Sprint(output.GetValue()),
+			})
+		}
+	}
+
+	// Transfers are numbered within their transaction.
+	seen := make(map[utils.Uint256]int)
+	for _, transfer := range transfers {
+		n := seen[transfer.TxId]
+		seen[transfer.TxId]++
+		if transfer.To == "" {
+			continue
+		}
+		hash, ok := neo.GetPublicKeyHashFromAddress(transfer.To)
+		if !ok {
+			continue
+		}
+		address, ok := w.watchedAddress(hash)
+		if !ok {
+			continue
+		}
+		txid := transfer.TxId.String()
+		deposits = append(deposits, Deposit{
+			Id:      fmt.Sprintf("%s:nep5:%d", txid, n),
+			Kind:    Nep5,
+			TxId:    txid,
+			Height:  block.Index,
+			Address: address,
+			Asset:   "0x" + transfer.Contract.String(),
+			Amount:  transfer.Amount.String(),
+		})
+	}
+	return deposits
+}
+
+// Sign returns the signature of a webhook body made with secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature, the SignatureHeader of a
+// webhook call, was made with secret over body.
+func VerifySignature(secret, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Deliver posts d to the webhook, retrying until it answers with a 2xx status.
+func (w *Watcher) Deliver(ctx context.Context, d Deposit) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	delay := w.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, body)
+		if err == nil || attempt >= w.Retries || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("deliver %s: %v", d.Id, err)
+	}
+	return nil
+}
+
+func (w *Watcher) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// confirmedSource hides the blocks with fewer than depth confirmations.
+type confirmedSource struct {
+	*rpc.Client
+	depth uint32
+}
+
+func (s confirmedSource) GetBlockCount(ctx context.Context) (uint32, error) {
+	count, err := s.Client.GetBlockCount(ctx)
+	if err != nil || s.depth <= 1 {
+		return count, err
+	}
+	if count < s.depth-1 {
+		return 0, nil
+	}
+	return count - (s.depth - 1), nil
+}
+
+// Run scans the chain from the height in cursor, or start when it holds
+// none, delivering the deposits of every confirmed block before moving on.
+// It returns when ctx is done or a deposit cannot be delivered; the cursor
+// then makes the next run deliver the deposits that were not acknowledged.
+func (w *Watcher) Run(ctx context.Context, cursor blockstream.CursorStore, start uint32) error {
+	stream := blockstream.New(confirmedSource{w.Client, w.Confirmations}, cursor)
+	stream.Start = start
+	if w.PollInterval > 0 {
+		stream.PollInterval = w.PollInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for block := range stream.Blocks(ctx) {
+		transfers, err := w.Client.GetBlockNep5Transfers(ctx, block)
+		if err != nil {
+			return err
+		}
+		for _, d := range w.Scan(block, transfers) {
+			if err := w.Deliver(ctx, d); err != nil {
+				return err
+			}
+		}
+	}
+	return stream.Err()
+}
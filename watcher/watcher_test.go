@@ -0,0 +1,249 @@
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hzxiao/neo-thinsdk-go/blockstream"
+	"github.com/hzxiao/neo-thinsdk-go/neo"
+	"github.com/hzxiao/neo-thinsdk-go/rpc"
+	"github.com/hzxiao/neo-thinsdk-go/utils"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	alice = "ARbjp1wPh5XJchZpSjqHzGVQnnpTxNR1x7"
+	bob   = "APxpKoFCfBk8RjkRdKwyUnsBntDRXLYAZc"
+	token = "ecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9"
+)
+
+// node serves blocks and the application log of their invocations.
+type node struct {
+	mu     sync.Mutex
+	height uint32
+	blocks []*neo.Block
+	logs   map[string]interface{}
+}
+
+func (n *node) grow(height uint32) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.height = height
+}
+
+func (n *node) serve(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+			Id     uint64            `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		n.mu.Lock()
+		var result interface{}
+		switch req.Method {
+		case "getblockcount":
+			result = n.height
+		case "getblock":
+			var index uint32
+			json.Unmarshal(req.Params[0], &index)
+			buf := &bytes.Buffer{}
+			n.blocks[index].Serialize(buf)
+			result = hex.EncodeToString(buf.Bytes())
+		case "getapplicationlog":
+			var txid string
+			json.Unmarshal(req.Params[0], &txid)
+			result = n.logs[txid]
+		}
+		n.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result})
+	}))
+}
+
+func block(index uint32, tx *neo.Transaction) *neo.Block {
+	b := &neo.Block{Header: neo.Header{Index: index}, Transactions: []*neo.Transaction{tx}}
+	b.RebuildMerkleRoot()
+	return b
+}
+
+func tx(t *testing.T, txType byte, params *neo.CreateSignParams) *neo.Transaction {
+	tx, err := neo.BuildTx(txType, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// chain returns a node with three blocks: alice pays 1 NEO to bob, then 5
+// tokens, then 2 NEO.
+func chain(t *testing.T) *node {
+	pay := func(hash string, value uint64) *neo.Transaction {
+		return tx(t, neo.ContractTransaction, &neo.CreateSignParams{
+			From:    alice,
+			To:      bob,
+			AssetId: neo.NeoAssetId,
+			Value:   value,
+			Utxos:   []neo.Utxo{{Hash: hash, Value: 10 * neo.D}},
+		})
+	}
+	contract, _ := utils.Uint160DecodeString(token)
+	from, _ := neo.AddressToScriptHash(alice)
+	to, _ := neo.AddressToScriptHash(bob)
+	invocation := tx(t, neo.InvocationTransaction, &neo.CreateSignParams{
+		From:    alice,
+		AssetId: neo.GasAssetId,
+		Data:    neo.Nep5TransferScript(contract, from, to, big.NewInt(5)),
+		Gas:     1,
+		Utxos:   []neo.Utxo{{Hash: "b80f65fc5c0cc9a24ae2d613770202aae95dfa598f6541f75987b747eb5ca830", Value: neo.D}},
+	})
+
+	txid := invocation.GetTxId().String()
+	transfer := func(hash utils.Uint160) map[string]interface{} {
+		return map[string]interface{}{"type": "ByteArray", "value": hex.EncodeToString(hash.BytesReverse())}
+	}
+	n := &node{
+		height: 3,
+		blocks: []*neo.Block{
+			block(0, pay("a8e2b4f0c0bfc7d6c2f0c0a1e2b3d4c5f6a7b8c9d0e1f2a3b4c5d6e7f8091a2b", neo.D)),
+			block(1, invocation),
+			block(2, pay("c5d6e7f8091a2ba8e2b4f0c0bfc7d6c2f0c0a1e2b3d4c5f6a7b8c9d0e1f2a3b4", 2*neo.D)),
+		},
+		logs: map[string]interface{}{txid: map[string]interface{}{
+			"txid": "0x" + txid,
+			"executions": []interface{}{map[string]interface{}{
+				"vmstate": "HALT",
+				"notifications": []interface{}{map[string]interface{}{
+					"contract": "0x" + token,
+					"state": map[string]interface{}{"type": "Array", "value": []interface{}{
+						map[string]interface{}{"type": "ByteArray", "value": hex.EncodeToString([]byte("transfer"))},
+						transfer(from),
+						transfer(to),
+						map[string]interface{}{"type": "Integer", "value": "5"},
+					}},
+				}},
+			}},
+		}},
+	}
+	return n
+}
+
+// webhook accepts the deposits signed with secret after failing once.
+func webhook(t *testing.T, secret []byte, deposits chan<- Deposit) *httptest.Server {
+	var mu sync.Mutex
+	failed := false
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !VerifySignature(secret, body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("bad signature of %s", body)
+		}
+		mu.Lock()
+		fail := !failed
+		failed = true
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var d Deposit
+		json.Unmarshal(body, &d)
+		deposits <- d
+	}))
+}
+
+// run runs w until it delivers as many deposits as expected.
+func run(t *testing.T, w *Watcher, cursor blockstream.CursorStore, deposits <-chan Deposit, expected []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx, cursor, 0) }()
+	for _, id := range expected {
+		select {
+		case d := <-deposits:
+			if d.Id != id {
+				t.Fatalf("deposit %+v, expected %s", d, id)
+			}
+			if d.Address != bob {
+				t.Fatalf("deposit to %s", d.Address)
+			}
+		case err := <-done:
+			t.Fatalf("watcher stopped: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no deposit %s", id)
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("watcher error %v", err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	n := chain(t)
+	server := n.serve(t)
+	defer server.Close()
+	secret := []byte("secret")
+	deposits := make(chan Deposit, 10)
+	hook := webhook(t, secret, deposits)
+	defer hook.Close()
+
+	w := New(rpc.NewClient(server.URL), hook.URL, secret)
+	w.Confirmations = 2
+	w.RetryDelay = time.Millisecond
+	w.PollInterval = time.Millisecond
+	if err := w.Watch(bob); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, 3)
+	for i, b := range n.blocks {
+		ids[i] = b.Transactions[0].GetTxId().String()
+	}
+	nep5 := Deposit{
+		Id:      ids[1] + ":nep5:0",
+		Kind:    Nep5,
+		TxId:    ids[1],
+		Height:  1,
+		Address: bob,
+		Asset:   "0x" + token,
+		Amount:  "5",
+	}
+
+	// Block 2 waits for a second confirmation.
+	cursor := &blockstream.MemoryCursor{}
+	run(t, w, cursor, deposits, []string{ids[0] + ":0", nep5.Id})
+
+	// The last block is delivered again on restart.
+	n.grow(4)
+	run(t, w, cursor, deposits, []string{nep5.Id, ids[2] + ":0"})
+
+	transfers, err := w.Client.GetBlockNep5Transfers(context.Background(), n.blocks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Scan(n.blocks[1], transfers); len(got) != 1 || got[0] != nep5 {
+		t.Fatalf("scanned %+v", got)
+	}
+	if got := w.Scan(n.blocks[0], nil); len(got) != 1 || got[0].Kind != UTXO || got[0].Asset != "0x"+neo.NeoAssetId || got[0].Amount != "100000000" {
+		t.Fatalf("scanned %+v", got)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign([]byte("secret"), body)
+	if !VerifySignature([]byte("secret"), body, signature) {
+		t.Fatal("signature not verified")
+	}
+	if VerifySignature([]byte("other"), body, signature) || VerifySignature([]byte("secret"), body, "zz") {
+		t.Fatal("wrong signature verified")
+	}
+}